@@ -0,0 +1,421 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// flushInterval is how often the checkpoint gets written to disk while the program runs. The created sources are
+// written right away instead, since they are the parents of everything else.
+const flushInterval = 5 * time.Second
+
+// State is the progress of a run, which gets written to the checkpoint file.
+type State struct {
+	Tenants []*Tenant `json:"tenants"`
+}
+
+// Tenant holds the progress of a single tenant. The sources that were fully created, sub resources included, are only
+// kept as a count along with their indexes, whereas the sources which still have sub resources to be created are kept
+// along with what they already have. The number of sources sampled for the tenant is kept too, so that a resumed run
+// creates the same number of sources.
+type Tenant struct {
+	Identity               string             `json:"identity"`
+	TargetSources          int                `json:"target_sources"`
+	CompletedSources       int                `json:"completed_sources"`
+	CompletedSourceIndexes []int              `json:"completed_source_indexes"`
+	PendingSources         map[string]*Source `json:"pending_sources"`
+}
+
+// Source holds the number of sub resources that have already been created for a source, along with their indexes. The
+// source's index in the tenant and the sub resources' indexes are what their random data is generated from.
+type Source struct {
+	Id                    string                  `json:"id"`
+	Index                 int                     `json:"index"`
+	SourceTypeId          string                  `json:"source_type_id"`
+	Targets               SourceTargets           `json:"targets"`
+	Authentications       int                     `json:"authentications"`
+	AuthenticationIndexes []int                   `json:"authentication_indexes"`
+	Endpoints             int                     `json:"endpoints"`
+	EndpointIndexes       []int                   `json:"endpoint_indexes"`
+	RhcConnections        int                     `json:"rhc_connections"`
+	RhcConnectionIndexes  []int                   `json:"rhc_connection_indexes"`
+	Applications          map[string]*Application `json:"applications"`
+}
+
+// SourceTargets holds the number of sub resources that were sampled for a source.
+type SourceTargets struct {
+	Applications    int `json:"applications"`
+	Authentications int `json:"authentications"`
+	Endpoints       int `json:"endpoints"`
+	RhcConnections  int `json:"rhc_connections"`
+}
+
+// Application holds the ID of an application that was created for a source, and the number of authentications that
+// were sampled for it and created for it, along with the created authentications' indexes.
+type Application struct {
+	Id                    string `json:"id"`
+	TargetAuthentications int    `json:"target_authentications"`
+	Authentications       int    `json:"authentications"`
+	AuthenticationIndexes []int  `json:"authentication_indexes"`
+}
+
+// checkpointFile is the path of the file the checkpoint is written to. When it is empty, the checkpointing is disabled.
+var checkpointFile string
+
+// done signals the flushing goroutine to stop.
+var done chan struct{}
+
+// dirty is true when the state has changed since the last time it was written to disk.
+var dirty bool
+
+// flushMutex makes sure that the checkpoint is written by one goroutine at a time, so that an older state never
+// replaces a newer one on disk.
+var flushMutex sync.Mutex
+
+// mutex protects the state from being modified concurrently.
+var mutex sync.Mutex
+
+// state is the progress of the current run.
+var state *State
+
+// tenants is a helper map for a quick lookup of the tenants in the state.
+var tenants map[string]*Tenant
+
+// wg allows waiting for the flushing goroutine to finish.
+var wg sync.WaitGroup
+
+// InitializeCheckpoint sets up the checkpointing of the run. When resuming a previous run, the tenants from the
+// checkpoint replace the configured ones, so that the remaining resources are created for the very same tenants.
+// Otherwise, the number of sources of every tenant is sampled with the given function and recorded right away.
+func InitializeCheckpoint(sampleTargetSources func(tenant string) int) {
+	checkpointFile = config.CheckpointFile
+	if checkpointFile == "" {
+		checkpointFile = config.ResumeFile
+	}
+
+	if checkpointFile == "" {
+		return
+	}
+
+	if config.ResumeFile != "" {
+		content, err := os.ReadFile(config.ResumeFile)
+		if err != nil {
+			logger.Logger.Fatalw(
+				"could not read the checkpoint file to resume from",
+				zap.Error(err),
+				zap.String("resume_file", config.ResumeFile),
+			)
+		}
+
+		state = &State{}
+		if err := json.Unmarshal(content, state); err != nil {
+			logger.Logger.Fatalw(
+				"could not parse the checkpoint file to resume from",
+				zap.Error(err),
+				zap.String("resume_file", config.ResumeFile),
+			)
+		}
+
+		config.Tenants = nil
+		for _, tenant := range state.Tenants {
+			config.Tenants = append(config.Tenants, tenant.Identity)
+		}
+
+		logger.Logger.Infow(
+			"Resuming from checkpoint",
+			zap.String("resume_file", config.ResumeFile),
+			zap.Int("tenants", len(state.Tenants)),
+		)
+	} else {
+		state = &State{}
+		for _, tenant := range config.Tenants {
+			state.Tenants = append(state.Tenants, &Tenant{Identity: tenant, TargetSources: sampleTargetSources(tenant)})
+		}
+	}
+
+	tenants = make(map[string]*Tenant, len(state.Tenants))
+	for _, tenant := range state.Tenants {
+		if tenant.PendingSources == nil {
+			tenant.PendingSources = make(map[string]*Source)
+		}
+
+		tenants[tenant.Identity] = tenant
+	}
+
+	// Write the initial state right away, so that the tenants are recorded even if the program dies before the first
+	// flush.
+	dirty = true
+	flush()
+
+	done = make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				flush()
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// CloseCheckpoint stops the periodic flushing and writes the final state to disk.
+func CloseCheckpoint() {
+	if state == nil {
+		return
+	}
+
+	close(done)
+	wg.Wait()
+
+	flush()
+}
+
+// GetPendingSources returns a copy of the sources of the tenant which still have sub resources to be created.
+func GetPendingSources(tenant string) []Source {
+	if state == nil {
+		return nil
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var sources []Source
+	for _, source := range tenants[tenant].PendingSources {
+		src := *source
+		src.AuthenticationIndexes = append([]int(nil), source.AuthenticationIndexes...)
+		src.EndpointIndexes = append([]int(nil), source.EndpointIndexes...)
+		src.RhcConnectionIndexes = append([]int(nil), source.RhcConnectionIndexes...)
+
+		src.Applications = make(map[string]*Application, len(source.Applications))
+		for appTypeId, app := range source.Applications {
+			application := *app
+			application.AuthenticationIndexes = append([]int(nil), app.AuthenticationIndexes...)
+			src.Applications[appTypeId] = &application
+		}
+
+		sources = append(sources, src)
+	}
+
+	return sources
+}
+
+// GetTargetSources returns the number of sources to create for the tenant, which is the recorded one when the
+// checkpointing is enabled, so that a resumed run creates the same number of sources. Otherwise, the number is sampled
+// with the given function.
+func GetTargetSources(tenant string, sampleTargetSources func(tenant string) int) int {
+	if state == nil {
+		return sampleTargetSources(tenant)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return tenants[tenant].TargetSources
+}
+
+// GetRemainingSourceIndexes returns the indexes of the sources that still need to be created for the tenant. These are
+// the lowest indexes which are not taken by any completed or pending source, so that the sources which failed to be
+// created in a previous run get created with the same index they would have had.
+func GetRemainingSourceIndexes(tenant string, targetSources int) []int {
+	if state == nil {
+		return GetMissingIndexes(nil, targetSources)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	t := tenants[tenant]
+
+	taken := append([]int(nil), t.CompletedSourceIndexes...)
+	for _, source := range t.PendingSources {
+		taken = append(taken, source.Index)
+	}
+
+	return GetMissingIndexes(taken, targetSources-t.CompletedSources-len(t.PendingSources))
+}
+
+// GetMissingIndexes returns the given number of lowest indexes which are not among the taken ones.
+func GetMissingIndexes(taken []int, count int) []int {
+	var isTaken = make(map[int]bool, len(taken))
+	for _, index := range taken {
+		isTaken[index] = true
+	}
+
+	var indexes []int
+	for index := 0; len(indexes) < count; index++ {
+		if !isTaken[index] {
+			indexes = append(indexes, index)
+		}
+	}
+
+	return indexes
+}
+
+// SourceCreated records a new source as pending, since its sub resources are yet to be created. The checkpoint is
+// written to disk before returning, so that a resumed run never creates the source again once its sub resources start
+// being created.
+func SourceCreated(tenant string, sourceId string, sourceIndex int, sourceTypeId string, targets SourceTargets) {
+	if state == nil {
+		return
+	}
+
+	update(tenant, func(t *Tenant) {
+		t.PendingSources[sourceId] = &Source{
+			Id:           sourceId,
+			Index:        sourceIndex,
+			SourceTypeId: sourceTypeId,
+			Targets:      targets,
+			Applications: make(map[string]*Application),
+		}
+	})
+
+	flush()
+}
+
+// SourceCompleted records that all the sub resources of the source have been created.
+func SourceCompleted(tenant string, sourceId string) {
+	update(tenant, func(t *Tenant) {
+		if source, ok := t.PendingSources[sourceId]; ok {
+			delete(t.PendingSources, sourceId)
+			t.CompletedSources++
+			t.CompletedSourceIndexes = append(t.CompletedSourceIndexes, source.Index)
+		}
+	})
+}
+
+// ApplicationCreated records a new application for the given source, along with the number of authentications that
+// were sampled for it.
+func ApplicationCreated(tenant string, sourceId string, applicationTypeId string, applicationId string, targetAuthentications int) {
+	updateSource(tenant, sourceId, func(s *Source) {
+		s.Applications[applicationTypeId] = &Application{Id: applicationId, TargetAuthentications: targetAuthentications}
+	})
+}
+
+// ApplicationAuthenticationCreated records a new authentication, with the given index, for the source's application of
+// the given type.
+func ApplicationAuthenticationCreated(tenant string, sourceId string, applicationTypeId string, index int) {
+	updateSource(tenant, sourceId, func(s *Source) {
+		if app, ok := s.Applications[applicationTypeId]; ok {
+			app.Authentications++
+			app.AuthenticationIndexes = append(app.AuthenticationIndexes, index)
+		}
+	})
+}
+
+// EndpointCreated records a new endpoint, with the given index, for the given source.
+func EndpointCreated(tenant string, sourceId string, index int) {
+	updateSource(tenant, sourceId, func(s *Source) {
+		s.Endpoints++
+		s.EndpointIndexes = append(s.EndpointIndexes, index)
+	})
+}
+
+// RhcConnectionCreated records a new rhc connection, with the given index, for the given source.
+func RhcConnectionCreated(tenant string, sourceId string, index int) {
+	updateSource(tenant, sourceId, func(s *Source) {
+		s.RhcConnections++
+		s.RhcConnectionIndexes = append(s.RhcConnectionIndexes, index)
+	})
+}
+
+// SourceAuthenticationCreated records a new authentication, with the given index, for the given source.
+func SourceAuthenticationCreated(tenant string, sourceId string, index int) {
+	updateSource(tenant, sourceId, func(s *Source) {
+		s.Authentications++
+		s.AuthenticationIndexes = append(s.AuthenticationIndexes, index)
+	})
+}
+
+// update applies the given function to the tenant while holding the lock, and marks the state as dirty.
+func update(tenant string, fn func(t *Tenant)) {
+	if state == nil {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	t, ok := tenants[tenant]
+	if !ok {
+		return
+	}
+
+	fn(t)
+	dirty = true
+}
+
+// updateSource applies the given function to the tenant's pending source, if it exists.
+func updateSource(tenant string, sourceId string, fn func(s *Source)) {
+	update(tenant, func(t *Tenant) {
+		if source, ok := t.PendingSources[sourceId]; ok {
+			fn(source)
+		}
+	})
+}
+
+// flush writes the state to the checkpoint file if it has changed. The state is first written to a temporary file
+// which then replaces the checkpoint file, so that a crash in the middle of a write never leaves a corrupted
+// checkpoint behind.
+func flush() {
+	flushMutex.Lock()
+	defer flushMutex.Unlock()
+
+	mutex.Lock()
+	if !dirty {
+		mutex.Unlock()
+		return
+	}
+
+	content, err := json.Marshal(state)
+	dirty = false
+	mutex.Unlock()
+
+	if err != nil {
+		logger.Logger.Errorw("could not marshal the checkpoint into JSON", zap.Error(err))
+		return
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(checkpointFile), filepath.Base(checkpointFile)+".tmp")
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not create the temporary checkpoint file",
+			zap.Error(err),
+			zap.String("checkpoint_file", checkpointFile),
+		)
+		return
+	}
+
+	_, err = tmpFile.Write(content)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), checkpointFile)
+	}
+
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not write the checkpoint file",
+			zap.Error(err),
+			zap.String("checkpoint_file", checkpointFile),
+		)
+
+		_ = os.Remove(tmpFile.Name())
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"github.com/MikelAlejoBR/sources-database-populator/manifest"
+	"github.com/MikelAlejoBR/sources-database-populator/metrics"
+	"github.com/MikelAlejoBR/sources-database-populator/request"
+	"go.uber.org/zap"
+)
+
+// listPageLimit is the number of resources we ask the back end for on each "list" request.
+const listPageLimit = 100
+
+// cleanupResource holds the name of a resource type and the URL of its collection, which is used both for listing the
+// resources and for deleting them.
+type cleanupResource struct {
+	Name string
+	Url  string
+}
+
+// deletionResult holds the number of successful and failed deletions for a resource type.
+type deletionResult struct {
+	Succeeded uint64 `json:"succeeded"`
+	Failed    uint64 `json:"failed"`
+}
+
+// getCleanupResources returns the resource types in the order they need to be deleted, which is the reverse order of
+// their dependencies: the authentications depend on the applications and the sources, the applications, endpoints and
+// rhc connections depend on the sources, and the sources come last.
+func getCleanupResources() []cleanupResource {
+	return []cleanupResource{
+		{Name: "authentication", Url: config.AuthenticationCreateUrl},
+		{Name: "application", Url: config.ApplicationCreateUrl},
+		{Name: "endpoint", Url: config.EndpointCreateUrl},
+		{Name: "rhcConnection", Url: config.RhcConnectionCreateUrl},
+		{Name: "source", Url: config.SourceCreateUrl},
+	}
+}
+
+// cleanup deletes either the resources recorded in the manifest file, or all the resources that the given tenants have
+// in the back end, and prints how many deletions succeeded or failed per resource type at the end.
+func cleanup() {
+	// Get the time before starting the process so that we can calculate the elapsed time afterwards.
+	startTs := time.Now()
+
+	resources := getCleanupResources()
+
+	results := make(map[string]*deletionResult, len(resources))
+	for _, resource := range resources {
+		results[resource.Name] = &deletionResult{}
+	}
+
+	// By default, we delete everything the tenants have. When a manifest is given, only the resources a previous run
+	// created get deleted.
+	tenants := config.Tenants
+	getIds := listResourceIds
+	if config.ManifestFile != "" {
+		tenants, getIds = loadManifestResourceIds()
+	}
+
+	for _, tenant := range tenants {
+		for _, resource := range resources {
+			ids := getIds(tenant, resource)
+
+			deleteResources(tenant, resource, ids, results[resource.Name])
+		}
+	}
+
+	// Calculate the elapsed time.
+	elapsedTime := time.Since(startTs).String()
+
+	summary := map[string]interface{}{
+		"elapsed_time":            elapsedTime,
+		"cleaned_tenants":         tenants,
+		"deleted_sources":         results["source"],
+		"deleted_endpoints":       results["endpoint"],
+		"deleted_applications":    results["application"],
+		"deleted_authentications": results["authentication"],
+		"deleted_rhc_connections": results["rhcConnection"],
+	}
+
+	// Just like in the "populate" mode, we print the results instead of logging them so that they are always visible
+	// regardless of the log level.
+	result, err := json.Marshal(summary)
+	if err == nil {
+		fmt.Println(string(result))
+	} else {
+		logger.Logger.Errorw(
+			"Could not format the results to JSON. Printing it on this log message",
+			zap.Error(err),
+			zap.String("elapsed_time", elapsedTime),
+			zap.Any("deleted_sources", results["source"]),
+			zap.Any("deleted_endpoints", results["endpoint"]),
+			zap.Any("deleted_applications", results["application"]),
+			zap.Any("deleted_authentications", results["authentication"]),
+			zap.Any("deleted_rhc_connections", results["rhcConnection"]),
+		)
+	}
+}
+
+// deleteResources deletes the resources with the given IDs concurrently, and it updates the given result with the
+// number of successful and failed deletions.
+func deleteResources(tenant string, resource cleanupResource, ids []string, result *deletionResult) {
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+
+			if sendDeletionRequest(resource.Name, tenant, fmt.Sprintf("%s/%s", resource.Url, id)) {
+				atomic.AddUint64(&result.Succeeded, 1)
+
+				logger.Logger.Infow(
+					"Resource deleted",
+					zap.String("resource_type", resource.Name),
+					zap.String("id", id),
+				)
+			} else {
+				atomic.AddUint64(&result.Failed, 1)
+			}
+		}(id)
+	}
+
+	wg.Wait()
+}
+
+// loadManifestResourceIds reads the manifest file and returns the tenants that appear in it, in the order they were
+// recorded, along with a function that returns the recorded IDs for the given tenant and resource type.
+func loadManifestResourceIds() ([]string, func(tenant string, resource cleanupResource) []string) {
+	entries, err := manifest.ReadManifest(config.ManifestFile)
+	if err != nil {
+		logger.Logger.Fatalw(
+			"could not read the manifest file",
+			zap.Error(err),
+			zap.String("manifest_file", config.ManifestFile),
+		)
+	}
+
+	var tenants []string
+	ids := make(map[string]map[string][]string)
+	for _, entry := range entries {
+		if _, ok := ids[entry.Tenant]; !ok {
+			tenants = append(tenants, entry.Tenant)
+			ids[entry.Tenant] = make(map[string][]string)
+		}
+
+		ids[entry.Tenant][entry.ResourceType] = append(ids[entry.Tenant][entry.ResourceType], entry.Id)
+	}
+
+	return tenants, func(tenant string, resource cleanupResource) []string {
+		return ids[tenant][resource.Name]
+	}
+}
+
+// listResourceIds fetches the IDs of all the resources of the given type that the tenant has, by paginating through
+// the resource's collection.
+func listResourceIds(tenant string, resource cleanupResource) []string {
+	// Temporary struct to be able to read the response, since it comes in the {"meta": {...}, "data": [{...}]} form.
+	type ListResponse struct {
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+		Data []IdStruct `json:"data"`
+	}
+
+	var ids []string
+	for offset := 0; ; {
+		url := fmt.Sprintf("%s?limit=%d&offset=%d", resource.Url, listPageLimit, offset)
+
+		resBody, isSuccess := sendListRequest(resource.Name, tenant, url)
+		if !isSuccess {
+			return ids
+		}
+
+		var page ListResponse
+		if err := json.Unmarshal(resBody, &page); err != nil {
+			logger.Logger.Errorw(
+				"could not extract the IDs from the list response. Skipping the rest of the resources...",
+				zap.Error(err),
+				zap.String("resource_type", resource.Name),
+				zap.String("tenant", tenant),
+				zap.Any("response_body", json.RawMessage(resBody)),
+			)
+			return ids
+		}
+
+		for _, id := range page.Data {
+			ids = append(ids, id.Id)
+		}
+
+		offset += len(page.Data)
+		if len(page.Data) == 0 || offset >= page.Meta.Count {
+			return ids
+		}
+	}
+}
+
+// sendListRequest sends a "GET" request to the given collection URL, and returns the response's body.
+func sendListRequest(resourceType string, tenant string, url string) ([]byte, bool) {
+	config.ConcurrentRequests <- struct{}{}
+	defer func() { <-config.ConcurrentRequests }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetTimeout(resourceType))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not create the list request. Skipping...",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return nil, false
+	}
+
+	request.SetHeaders(req, tenant)
+
+	logger.Logger.Debugw("List request to be sent", zap.Any("request", req))
+
+	metrics.RequestStarted(resourceType, tenant, http.MethodGet)
+	requestStartTs := time.Now()
+	res, err := request.Client.Do(req)
+	latency := time.Since(requestStartTs)
+	if err != nil {
+		metrics.ObserveRequest(resourceType, tenant, http.MethodGet, 0, latency, false)
+
+		logger.Logger.Errorw(
+			"could not send the list request. Skipping...",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return nil, false
+	}
+
+	metrics.ObserveRequest(resourceType, tenant, http.MethodGet, res.StatusCode, latency, res.StatusCode == http.StatusOK)
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not read the list response body",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+	}
+	if err = res.Body.Close(); err != nil {
+		logger.Logger.Errorw(
+			"could not close the list response body",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		logger.Logger.Errorw(
+			"unexpected status code when listing resources. Skipping...",
+			zap.Int("want_status_code", http.StatusOK),
+			zap.Any("response_body", json.RawMessage(resBody)),
+			zap.Int("got_status_code", res.StatusCode),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return nil, false
+	}
+
+	return resBody, true
+}
+
+// sendDeletionRequest is a generic function which sends a resource deletion request to the back end.
+func sendDeletionRequest(resourceType string, tenant string, url string) bool {
+	// The deletion requests share the same throttler as the creation ones.
+	config.ConcurrentRequests <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetTimeout(resourceType))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not create request for the resource deletion. Skipping...",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+
+		<-config.ConcurrentRequests
+		return false
+	}
+
+	request.SetHeaders(req, tenant)
+
+	logger.Logger.Debugw("Request to be sent", zap.Any("request", req))
+
+	metrics.RequestStarted(resourceType, tenant, http.MethodDelete)
+	requestStartTs := time.Now()
+	res, err := request.Client.Do(req)
+	latency := time.Since(requestStartTs)
+	if err != nil {
+		metrics.ObserveRequest(resourceType, tenant, http.MethodDelete, 0, latency, false)
+
+		logger.Logger.Errorw(
+			"could not send the deletion request. Skipping...",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+
+		<-config.ConcurrentRequests
+		return false
+	}
+
+	// Request is done, we can free one slot in the channel.
+	<-config.ConcurrentRequests
+
+	metrics.ObserveRequest(resourceType, tenant, http.MethodDelete, res.StatusCode, latency, res.StatusCode == http.StatusNoContent)
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not read the resource deletion's response body",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+	}
+	if err = res.Body.Close(); err != nil {
+		logger.Logger.Errorw(
+			"could not close the resource deletion's response body",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+	}
+
+	if res.StatusCode != http.StatusNoContent {
+		logger.Logger.Errorw(
+			"unexpected status code when deleting a resource. Skipping...",
+			zap.Int("want_status_code", http.StatusNoContent),
+			zap.Any("response_body", json.RawMessage(resBody)),
+			zap.Int("got_status_code", res.StatusCode),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// commandPlan is the command that runs the "populate" mode as a dry run.
+const commandPlan = "plan"
+
+// commandDescriptions holds the description of each command, in the order they are shown in the help message.
+var commandDescriptions = [][2]string{
+	{ModePopulate, "create the tenants, sources and their sub resources (default)"},
+	{commandPlan, "print the requests that \"populate\" would send, without contacting the back end"},
+	{ModeVerify, "check that the resources from a manifest, or the tenants' resources, exist in the back end"},
+	{ModeCleanup, "delete the resources from a manifest, or all the tenants' resources"},
+	{ModeDescribeTypes, "print the source type, application type and authentication type compatibility matrix"},
+}
+
+// envFlags holds the environment variables that can be also given as command line flags, along with their
+// descriptions. The flag's name is the environment variable's name in lower case, with dashes instead of underscores.
+var envFlags = [][2]string{
+	{"APPLICATIONS_PER_SOURCE", `number of applications to create per source, or a distribution such as "0-2"`},
+	{"AUTH_MODE", `authentication mode for the requests: "identity" or "psk"`},
+	{"AUTHENTICATIONS_PER_RESOURCE", `number of authentications to create per resource, or a distribution such as "1-5"`},
+	{"CHECKPOINT_FILE", "file where the progress of the run is written to"},
+	{"CONCURRENT_REQUESTS", "maximum number of requests to send at the same time"},
+	{"DATA_GENERATOR", `generator of the resources' field values: "realistic" or "uuid"`},
+	{"DESCRIBE_FORMAT", `format of the "describe-types" compatibility matrix: "table", "json" or "csv"`},
+	{"DRY_RUN", "only print the requests that would be sent"},
+	{"DRY_RUN_OUTPUT_FILE", "file where the planned requests are written to"},
+	{"EDGE_CASE_RATE", "probability, between 0 and 1, of a field value being an edge case"},
+	{"ENDPOINTS_PER_SOURCE", `number of endpoints to create per source, or a distribution such as "normal(10,3)"`},
+	{"EXCLUDE_APPLICATION_TYPES", "comma separated list of application types not to create"},
+	{"EXCLUDE_SOURCE_TYPES", `comma separated list of source types not to create, on top of "rh-marketplace"`},
+	{"EXISTING_TENANTS", `comma separated list of "account_number:org_id" pairs of existing tenants to use`},
+	{"EXISTING_TENANTS_FILE", `CSV file with the "account_number,org_id" pairs of existing tenants to use`},
+	{"INCLUDE_APPLICATION_TYPES", "comma separated list of the only application types to create"},
+	{"INCLUDE_SOURCE_TYPES", "comma separated list of the only source types to create"},
+	{"LOG_LEVEL", `log level: "debug", "info" or "error"`},
+	{"MANIFEST_FILE", "file where the created resources are recorded, or read from"},
+	{"MAX_RETRIES", "maximum number of retries for the failed creation requests"},
+	{"METRICS_ADDRESS", `address for the Prometheus metrics listener, such as ":9000"`},
+	{"NUMBER_OF_TENANTS", "number of tenants to generate"},
+	{"RATE_LIMIT", "maximum number of creation requests per second"},
+	{"RATE_LIMIT_RAMP_UP_DURATION", "time it takes to ramp up to the rate limit"},
+	{"RATE_LIMIT_RAMP_UP_FROM", "rate limit at the beginning of the ramp up"},
+	{"REQUEST_TIMEOUT", "default timeout for the requests"},
+	{"RESUME_FILE", "checkpoint file of a previous run to resume"},
+	{"RETRY_BASE_DELAY", "delay before the first retry, which doubles with every retry"},
+	{"RETRY_MAX_DELAY", "maximum delay between two retries"},
+	{"RHC_CONNECTIONS_PER_TENANT", `number of rhc connections to create per source, or a distribution such as "0-2"`},
+	{"SEED", "seed which makes the generated tenants and resources deterministic"},
+	{"SOURCE_TYPE_COUNTS", `comma separated list of "source_type_name:count" pairs of sources to create per tenant`},
+	{"SOURCE_TYPE_WEIGHTS", `comma separated list of "source_type_name:weight" pairs for picking the source types`},
+	{"SOURCE_TYPES_EXPORT_FILE", "file where the loaded source types and application types are exported to"},
+	{"SOURCE_TYPES_FILE", "local file with the source types and application types"},
+	{"SOURCES_API_HOST", "host of the Sources API, including the scheme"},
+	{"SOURCES_API_PORT", "port of the Sources API"},
+	{"SOURCES_PER_TENANT", `number of sources to create per tenant, or a distribution such as "zipf(1.5,100)"`},
+	{"SOURCES_PSK", `pre shared key for the "psk" authentication mode`},
+	{"STRICT_CATALOGUE", "exit when the source types and application types catalogue has integrity issues"},
+	{"TENANCY_MODE", `identifiers of the generated tenants: "account_number", "org_id", "both" or "mixed"`},
+	{"TENANTS", "comma separated list of base64 encoded identities to use"},
+	{"TLS_CA_BUNDLE", "PEM file with additional certificate authorities to trust"},
+	{"TLS_CLIENT_CERT", "PEM client certificate for mutual TLS"},
+	{"TLS_CLIENT_KEY", "PEM private key of the client certificate"},
+	{"TLS_INSECURE_SKIP_VERIFY", "skip the verification of the back end's certificate"},
+	{"TLS_SERVER_NAME", "server name for SNI and for verifying the back end's certificate"},
+}
+
+// boolEnvFlags holds the environment variables from "envFlags" which are booleans, so that their flags can be given
+// without a value, such as "--dry-run".
+var boolEnvFlags = map[string]bool{
+	"DRY_RUN":                  true,
+	"STRICT_CATALOGUE":         true,
+	"TLS_INSECURE_SKIP_VERIFY": true,
+}
+
+// parseCommandLine parses the command and the flags the program was called with. The flags that mirror environment
+// variables are exported to the environment, so that they take precedence over both the environment variables and the
+// configuration file. It returns the command and the path of the configuration file, if any.
+func parseCommandLine(args []string) (string, string) {
+	// The command is optional to keep backwards compatibility: when it is not given, the mode is taken from the "MODE"
+	// environment variable or the configuration file, and it defaults to populating the database.
+	var command string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+
+	switch command {
+	case "", commandPlan, ModeCleanup, ModeDescribeTypes, ModePopulate, ModeVerify:
+	default:
+		log.Fatalf(`unknown command "%s". Run the program with "--help" to see the available commands`, command)
+	}
+
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+	var configFile string
+	flagSet.StringVar(&configFile, "config", "", "path to a YAML or JSON configuration file")
+
+	// "--resume" is a shorter alias of the "--resume-file" flag.
+	var resumeFile string
+	flagSet.StringVar(&resumeFile, "resume", "", "resume the run recorded in the given checkpoint file (RESUME_FILE)")
+
+	for _, envFlag := range envFlags {
+		usage := fmt.Sprintf("%s (%s)", envFlag[1], envFlag[0])
+
+		if boolEnvFlags[envFlag[0]] {
+			flagSet.Bool(toFlagName(envFlag[0]), false, usage)
+		} else {
+			flagSet.String(toFlagName(envFlag[0]), "", usage)
+		}
+	}
+
+	flagSet.Usage = func() {
+		out := flagSet.Output()
+
+		fmt.Fprintf(out, "Usage: %s [command] [flags]\n\nCommands:\n", os.Args[0])
+		for _, description := range commandDescriptions {
+			fmt.Fprintf(out, "  %-16s %s\n", description[0], description[1])
+		}
+
+		fmt.Fprintf(out, "\nFlags:\n")
+		flagSet.PrintDefaults()
+	}
+
+	// "ExitOnError" makes the program exit if the flags are not valid.
+	_ = flagSet.Parse(args)
+
+	if flagSet.NArg() > 0 {
+		log.Fatalf(`unexpected arguments: %s`, strings.Join(flagSet.Args(), " "))
+	}
+
+	flagSet.Visit(func(f *flag.Flag) {
+		for _, envFlag := range envFlags {
+			if f.Name == toFlagName(envFlag[0]) {
+				if err := os.Setenv(envFlag[0], f.Value.String()); err != nil {
+					log.Fatalf(`could not set the "%s" flag: %s`, f.Name, err)
+				}
+			}
+		}
+	})
+
+	if resumeFile != "" {
+		if err := os.Setenv("RESUME_FILE", resumeFile); err != nil {
+			log.Fatalf(`could not set the "resume" flag: %s`, err)
+		}
+	}
+
+	return command, configFile
+}
+
+// toFlagName turns the given environment variable's name into its corresponding flag name.
+func toFlagName(envName string) string {
+	return strings.ReplaceAll(strings.ToLower(envName), "_", "-")
+}
@@ -0,0 +1,195 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Kinds of count distributions.
+const (
+	DistributionFixed   = "fixed"
+	DistributionNormal  = "normal"
+	DistributionUniform = "uniform"
+	DistributionZipf    = "zipf"
+)
+
+// CountSetting is a count as given in the configuration, which is either a number or a distribution specification such
+// as "5-20". It accepts both JSON numbers and JSON strings.
+type CountSetting string
+
+// UnmarshalJSON accepts both a number and a string.
+func (c *CountSetting) UnmarshalJSON(data []byte) error {
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err == nil {
+		*c = CountSetting(number)
+		return nil
+	}
+
+	var spec string
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf(`a count must be either a number or a string: %w`, err)
+	}
+
+	*c = CountSetting(spec)
+	return nil
+}
+
+// CountDistribution is the distribution that the number of resources to create gets sampled from.
+type CountDistribution struct {
+	// Kind is the kind of the distribution.
+	Kind string
+	// Min is the fixed count, or the lower bound of the uniform distribution.
+	Min int
+	// Max is the upper bound of the uniform and Zipf distributions.
+	Max int
+	// Mean is the mean of the normal distribution.
+	Mean float64
+	// StdDev is the standard deviation of the normal distribution.
+	StdDev float64
+	// Exponent is the exponent of the Zipf distribution, which must be greater than one. The greater it is, the more
+	// skewed towards zero the counts are.
+	Exponent float64
+}
+
+// parseCountDistribution parses a count specification, which can be one of:
+//
+//   - "10": always 10.
+//   - "5-20" or "uniform(5,20)": uniformly distributed between 5 and 20, both included.
+//   - "normal(10,3)": normally distributed with a mean of 10 and a standard deviation of 3, rounded to the nearest
+//     integer and never below zero.
+//   - "zipf(1.5,100)": Zipf distributed with an exponent of 1.5 between 0 and 100, which means that most of the counts
+//     are small and a few of them are large.
+func parseCountDistribution(spec string) (CountDistribution, error) {
+	spec = strings.ReplaceAll(strings.TrimSpace(spec), " ", "")
+
+	// A plain number is a fixed count.
+	if count, err := strconv.Atoi(spec); err == nil {
+		if count < 0 {
+			return CountDistribution{}, fmt.Errorf(`the count "%s" cannot be negative`, spec)
+		}
+
+		return CountDistribution{Kind: DistributionFixed, Min: count, Max: count}, nil
+	}
+
+	kind, arguments, err := parseDistributionFunction(spec)
+	if err != nil {
+		return CountDistribution{}, err
+	}
+
+	switch kind {
+	case DistributionUniform:
+		min, max, err := parseIntPair(arguments)
+		if err != nil || min < 0 || max < min {
+			return CountDistribution{}, fmt.Errorf(`invalid range "%s": the bounds must be two non negative integers, the lower one first`, spec)
+		}
+
+		return CountDistribution{Kind: DistributionUniform, Min: min, Max: max}, nil
+	case DistributionNormal:
+		mean, stdDev, err := parseFloatPair(arguments)
+		if err != nil || !isFinite(mean) || !isFinite(stdDev) || stdDev < 0 {
+			return CountDistribution{}, fmt.Errorf(`invalid normal distribution "%s": it takes a finite mean and a finite, non negative standard deviation`, spec)
+		}
+
+		return CountDistribution{Kind: DistributionNormal, Mean: mean, StdDev: stdDev}, nil
+	case DistributionZipf:
+		exponent, max, err := parseFloatPair(arguments)
+		if err != nil || !isFinite(exponent) || !isFinite(max) || exponent <= 1 || max < 0 || max != math.Trunc(max) {
+			return CountDistribution{}, fmt.Errorf(`invalid Zipf distribution "%s": it takes an exponent greater than one and a non negative integer maximum`, spec)
+		}
+
+		return CountDistribution{Kind: DistributionZipf, Exponent: exponent, Max: int(max)}, nil
+	default:
+		return CountDistribution{}, fmt.Errorf(`unknown distribution "%s". Valid distributions are "%s", "%s" and "%s"`, kind, DistributionUniform, DistributionNormal, DistributionZipf)
+	}
+}
+
+// parseDistributionFunction splits a "kind(a,b)" specification into its kind and its arguments. A "min-max" range is
+// taken as a uniform distribution.
+func parseDistributionFunction(spec string) (string, []string, error) {
+	if !strings.Contains(spec, "(") {
+		bounds := strings.SplitN(spec, "-", 2)
+		if len(bounds) != 2 {
+			return "", nil, fmt.Errorf(`invalid count "%s". It must be a number, a "min-max" range or a distribution such as "normal(10,3)"`, spec)
+		}
+
+		return DistributionUniform, bounds, nil
+	}
+
+	if !strings.HasSuffix(spec, ")") {
+		return "", nil, fmt.Errorf(`invalid distribution "%s": missing the closing parenthesis`, spec)
+	}
+
+	parts := strings.SplitN(strings.TrimSuffix(spec, ")"), "(", 2)
+
+	return strings.ToLower(parts[0]), strings.Split(parts[1], ","), nil
+}
+
+// parseIntPair parses the given two arguments as integers.
+func parseIntPair(arguments []string) (int, int, error) {
+	if len(arguments) != 2 {
+		return 0, 0, fmt.Errorf(`expected two arguments, got %d`, len(arguments))
+	}
+
+	first, err := strconv.Atoi(arguments[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	second, err := strconv.Atoi(arguments[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return first, second, nil
+}
+
+// parseFloatPair parses the given two arguments as floats.
+func parseFloatPair(arguments []string) (float64, float64, error) {
+	if len(arguments) != 2 {
+		return 0, 0, fmt.Errorf(`expected two arguments, got %d`, len(arguments))
+	}
+
+	first, err := strconv.ParseFloat(arguments[0], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	second, err := strconv.ParseFloat(arguments[1], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return first, second, nil
+}
+
+// isFinite returns true when the given number is neither infinite nor NaN, which "strconv.ParseFloat" accepts.
+func isFinite(number float64) bool {
+	return !math.IsInf(number, 0) && !math.IsNaN(number)
+}
+
+// Sample returns a count drawn from the distribution with the given random generator.
+func (d CountDistribution) Sample(r *rand.Rand) int {
+	switch d.Kind {
+	case DistributionUniform:
+		return d.Min + r.Intn(d.Max-d.Min+1)
+	case DistributionNormal:
+		count := int(math.Round(r.NormFloat64()*d.StdDev + d.Mean))
+		if count < 0 {
+			return 0
+		}
+
+		return count
+	case DistributionZipf:
+		if d.Max == 0 {
+			return 0
+		}
+
+		return int(rand.NewZipf(r, d.Exponent, 1, uint64(d.Max)).Uint64())
+	default:
+		return d.Min
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Settings holds every knob of the program. The defaults get overridden by the values from the configuration file,
+// which in turn get overridden by the environment variables.
+type Settings struct {
+	ApplicationsPerSource      CountSetting        `json:"applications_per_source" yaml:"applications_per_source"`
+	AuthMode                   string              `json:"auth_mode" yaml:"auth_mode"`
+	AuthenticationsPerResource CountSetting        `json:"authentications_per_resource" yaml:"authentications_per_resource"`
+	CheckpointFile             string              `json:"checkpoint_file" yaml:"checkpoint_file"`
+	ConcurrentRequests         int                 `json:"concurrent_requests" yaml:"concurrent_requests"`
+	DataGenerator              string              `json:"data_generator" yaml:"data_generator"`
+	DescribeFormat             string              `json:"describe_format" yaml:"describe_format"`
+	DryRun                     bool                `json:"dry_run" yaml:"dry_run"`
+	DryRunOutputFile           string              `json:"dry_run_output_file" yaml:"dry_run_output_file"`
+	EdgeCaseRate               float64             `json:"edge_case_rate" yaml:"edge_case_rate"`
+	EndpointsPerSource         CountSetting        `json:"endpoints_per_source" yaml:"endpoints_per_source"`
+	ExcludeApplicationTypes    []string            `json:"exclude_application_types" yaml:"exclude_application_types"`
+	ExcludeSourceTypes         []string            `json:"exclude_source_types" yaml:"exclude_source_types"`
+	ExistingTenants            []TenantIdentifiers `json:"existing_tenants" yaml:"existing_tenants"`
+	ExistingTenantsFile        string              `json:"existing_tenants_file" yaml:"existing_tenants_file"`
+	IncludeApplicationTypes    []string            `json:"include_application_types" yaml:"include_application_types"`
+	IncludeSourceTypes         []string            `json:"include_source_types" yaml:"include_source_types"`
+	LogLevel                   string              `json:"log_level" yaml:"log_level"`
+	ManifestFile               string              `json:"manifest_file" yaml:"manifest_file"`
+	MaxRetries                 int                 `json:"max_retries" yaml:"max_retries"`
+	MetricsAddress             string              `json:"metrics_address" yaml:"metrics_address"`
+	Mode                       string              `json:"mode" yaml:"mode"`
+	NumberOfTenants            int                 `json:"number_of_tenants" yaml:"number_of_tenants"`
+	Psk                        string              `json:"psk" yaml:"psk"`
+	RateLimits                 RateLimitSettings   `json:"rate_limits" yaml:"rate_limits"`
+	ResumeFile                 string              `json:"resume_file" yaml:"resume_file"`
+	RetryBaseDelay             string              `json:"retry_base_delay" yaml:"retry_base_delay"`
+	RetryMaxDelay              string              `json:"retry_max_delay" yaml:"retry_max_delay"`
+	RhcConnectionsPerTenant    CountSetting        `json:"rhc_connections_per_tenant" yaml:"rhc_connections_per_tenant"`
+	Seed                       string              `json:"seed" yaml:"seed"`
+	SourceTypesExportFile      string              `json:"source_types_export_file" yaml:"source_types_export_file"`
+	SourceTypesFile            string              `json:"source_types_file" yaml:"source_types_file"`
+	SourceTypeCounts           map[string]int      `json:"source_type_counts" yaml:"source_type_counts"`
+	SourceTypeWeights          map[string]float64  `json:"source_type_weights" yaml:"source_type_weights"`
+	SourcesApiHost             string              `json:"sources_api_host" yaml:"sources_api_host"`
+	SourcesApiPort             int                 `json:"sources_api_port" yaml:"sources_api_port"`
+	SourcesPerTenant           CountSetting        `json:"sources_per_tenant" yaml:"sources_per_tenant"`
+	StrictCatalogue            bool                `json:"strict_catalogue" yaml:"strict_catalogue"`
+	TenancyMode                string              `json:"tenancy_mode" yaml:"tenancy_mode"`
+	Tenants                    []string            `json:"tenants" yaml:"tenants"`
+	Tls                        TlsSettings         `json:"tls" yaml:"tls"`
+	Timeouts                   TimeoutSettings     `json:"timeouts" yaml:"timeouts"`
+}
+
+// TlsSettings holds the TLS configuration for the connections to the back end. The paths point to PEM files.
+type TlsSettings struct {
+	CaBundle           string `json:"ca_bundle" yaml:"ca_bundle"`
+	ClientCert         string `json:"client_cert" yaml:"client_cert"`
+	ClientKey          string `json:"client_key" yaml:"client_key"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+	ServerName         string `json:"server_name" yaml:"server_name"`
+}
+
+// TimeoutSettings holds the timeouts for the different requests the program sends, in the format that
+// "time.ParseDuration" accepts. The timeouts of the resources which are left empty default to the "default" one.
+type TimeoutSettings struct {
+	Default        string `json:"default" yaml:"default"`
+	Catalogue      string `json:"catalogue" yaml:"catalogue"`
+	HealthCheck    string `json:"health_check" yaml:"health_check"`
+	TenantInit     string `json:"tenant_initialization" yaml:"tenant_initialization"`
+	Application    string `json:"application" yaml:"application"`
+	Authentication string `json:"authentication" yaml:"authentication"`
+	Endpoint       string `json:"endpoint" yaml:"endpoint"`
+	RhcConnection  string `json:"rhc_connection" yaml:"rhc_connection"`
+	Source         string `json:"source" yaml:"source"`
+}
+
+// RateLimitSettings holds the maximum number of creation requests per second, both overall and per resource type. A
+// zero limit means that the requests are not rate limited. The overall limit can optionally be ramped up linearly from
+// the "ramp_up_from" rate during the "ramp_up_duration", which is in the format that "time.ParseDuration" accepts.
+type RateLimitSettings struct {
+	RequestsPerSecond float64 `json:"requests_per_second" yaml:"requests_per_second"`
+	RampUpFrom        float64 `json:"ramp_up_from" yaml:"ramp_up_from"`
+	RampUpDuration    string  `json:"ramp_up_duration" yaml:"ramp_up_duration"`
+	Application       float64 `json:"application" yaml:"application"`
+	Authentication    float64 `json:"authentication" yaml:"authentication"`
+	Endpoint          float64 `json:"endpoint" yaml:"endpoint"`
+	RhcConnection     float64 `json:"rhc_connection" yaml:"rhc_connection"`
+	Source            float64 `json:"source" yaml:"source"`
+}
+
+// readConfigFile reads the given YAML or JSON configuration file on top of the given settings, so that only the
+// values present in the file get overridden. The format is picked by the file's extension, and anything that is not a
+// ".json" file is read as YAML. In both formats the unknown keys are an error, so that a typo doesn't go unnoticed.
+func readConfigFile(path string, settings *Settings) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+
+		return decoder.Decode(settings)
+	}
+
+	return yaml.UnmarshalStrict(content, settings)
+}
@@ -1,18 +1,19 @@
 package config
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
-	"github.com/redhatinsights/platform-go-middlewares/identity"
+	"github.com/MikelAlejoBR/sources-database-populator/random"
 )
 
-// defaultApplicationsPerSource is the default number of applications that will be created per source_types_db.
+// defaultApplicationsPerSource is the default number of applications that will be created per source.
 const defaultApplicationsPerSource = 10
 
 // defaultAuthenticationsPerResource is the default number of authentications that will be created per resource.
@@ -21,35 +22,190 @@ const defaultAuthenticationsPerResource = 3
 // defaultConcurrentRequests is the default number of requests that the program is allowed to send at the same time.
 const defaultConcurrentRequests = 10
 
+// defaultMaxRetries is the default number of times a failed creation request is retried.
+const defaultMaxRetries = 3
+
+// defaultRequestTimeout is the default timeout for the creation and deletion requests.
+const defaultRequestTimeout = 10 * time.Second
+
+// defaultShortTimeout is the default timeout for the health check, tenant initialization and catalogue requests, which
+// should be quick to respond.
+const defaultShortTimeout = 3 * time.Second
+
 // defaultEndpointsPerSource is the default number of endpoints that will be created per source_types_db.
 const defaultEndpointsPerSource = 10
 
+// defaultRetryBaseDelay is the default delay before the first retry of a failed creation request, which doubles with
+// every subsequent retry.
+const defaultRetryBaseDelay = 500 * time.Millisecond
+
+// defaultRetryMaxDelay is the default maximum delay between two retries of a failed creation request.
+const defaultRetryMaxDelay = 30 * time.Second
+
 // defaultRhcConnectionsPerTenant is the default number of rhcConnections that will be created per source_types_db.
 const defaultRhcConnectionsPerTenant = 10
 
 // defaultSourcesPerTenant is the default number of sources that will be created per tenant.
 const defaultSourcesPerTenant = 10
 
-// defaultTenants is the default number of tenants that will be created.
+// defaultTenants is the default number of tenants that will be created, when the user didn't specify any tenants.
 const defaultTenants = 3
 
+// unsetNumberOfTenants signals that the user didn't specify the number of tenants to generate.
+const unsetNumberOfTenants = -1
+
+// defaultExcludedSourceTypes holds the source types which are always excluded, on top of the ones the user specifies.
+// The "rh-marketplace" source type doesn't have any compatible applications or authentications.
+var defaultExcludedSourceTypes = []string{"rh-marketplace"}
+
 // sourcesV31Path is the path to the latest API version.
 const sourcesV31Path = "api/sources/v3.1"
 
-// AuthenticationsPerResource is the number of authentications the program will create for each resource.
-var AuthenticationsPerResource int
+// Authentication modes for the requests sent to the back end.
+const (
+	AuthModeIdentity = "identity"
+	AuthModePsk      = "psk"
+)
+
+// Formats in which the "describe-types" mode prints the compatibility matrix.
+const (
+	DescribeFormatCsv   = "csv"
+	DescribeFormatJson  = "json"
+	DescribeFormatTable = "table"
+)
+
+// Modes in which the program can run.
+const (
+	ModeCleanup       = "cleanup"
+	ModeDescribeTypes = "describe-types"
+	ModePopulate      = "populate"
+	ModeVerify        = "verify"
+)
+
+// ApplicationsPerSource is the distribution of the number of applications the program will create for each source. The
+// applications get a random subset of the source type's compatible application types, so a source never gets more
+// applications than compatible application types.
+var ApplicationsPerSource CountDistribution
+
+// AuthMode is the way the requests get authenticated against the back end. It is either "identity", which sends the
+// tenants in the "x-rh-identity" header, or "psk", which sends the pre shared key along with the tenants' org IDs and
+// account numbers.
+var AuthMode string
+
+// AuthenticationsPerResource is the distribution of the number of authentications the program will create for each
+// resource.
+var AuthenticationsPerResource CountDistribution
+
+// CheckpointFile is the path to the file where the progress of the run is periodically written to, so that the run can
+// be resumed if it dies halfway.
+var CheckpointFile string
+
+// CatalogueTimeout is the timeout for the requests that fetch the source types and the application types.
+var CatalogueTimeout time.Duration
 
 // ConcurrentRequests is the maximum number of concurrent requests that the program is allowed to send at the same time.
 var ConcurrentRequests chan struct{}
 
-// EndpointsPerSource is the number of endpoints the program will create for each source_types_db.
-var EndpointsPerSource int
+// DataGenerator is the name of the generator of the resources' names, hosts, usernames and other field values.
+var DataGenerator string
+
+// DescribeFormat is the format in which the "describe-types" mode prints the compatibility matrix of the source types,
+// application types and authentication types. It is either "table", "json" or "csv".
+var DescribeFormat string
+
+// DryRun is true when the program should only print the requests it would send, without touching the back end.
+var DryRun bool
+
+// DryRunOutputFile is the path of the file the planned requests are written to in the dry run. When empty, they are
+// printed to the standard output.
+var DryRunOutputFile string
+
+// EdgeCaseRate is the probability of a generated field value being replaced with an edge case, such as a unicode, a
+// maximum length or a special characters string.
+var EdgeCaseRate float64
+
+// EndpointsPerSource is the distribution of the number of endpoints the program will create for each source.
+var EndpointsPerSource CountDistribution
+
+// Type filters, by name, which get applied when loading the source types and application types. When an inclusion list
+// is not empty only the types in it are loaded, and the types in an exclusion list are never loaded.
+var (
+	ExcludeApplicationTypes []string
+	ExcludeSourceTypes      []string
+	IncludeApplicationTypes []string
+	IncludeSourceTypes      []string
+)
+
+// HealthCheckTimeout is the timeout for the health check request.
+var HealthCheckTimeout time.Duration
 
 // LogLevel is the log level the logger will be configured at.
 var LogLevel string
 
-// RhcConnectionsPerTenant is the number of rhcConnections the program will create for each tenant.
-var RhcConnectionsPerTenant int
+// ManifestFile is the path to the file where every created resource gets recorded, one JSON object per line. In the
+// cleanup mode, the resources recorded in this file are the ones that get deleted.
+var ManifestFile string
+
+// MaxRetries is the maximum number of times a creation request is retried when it fails with a transient error.
+var MaxRetries int
+
+// MetricsAddress is the address the metrics listener binds to. When empty, the metrics are not exposed.
+var MetricsAddress string
+
+// Mode is the mode the program will run in. It is either "populate", which creates the fixtures, "cleanup", which
+// deletes the resources of the given tenants, "verify", which checks that the resources exist, or "describe-types",
+// which prints the catalogue of types.
+var Mode string
+
+// Psk is the pre shared key sent in the "x-rh-sources-psk" header in the "psk" authentication mode.
+var Psk string
+
+// RateLimit is the maximum number of creation requests per second that the program sends overall. When zero, the
+// requests are only limited by the maximum number of concurrent requests.
+var RateLimit float64
+
+// RateLimitRampUpDuration is the time it takes for the rate limit to ramp up from "RateLimitRampUpFrom" to "RateLimit".
+// When zero, the rate limit applies from the beginning.
+var RateLimitRampUpDuration time.Duration
+
+// RateLimitRampUpFrom is the rate limit at the beginning of the ramp up.
+var RateLimitRampUpFrom float64
+
+// RequestTimeout is the default timeout for the requests that create, list or delete resources.
+var RequestTimeout time.Duration
+
+// ResumeFile is the path to the checkpoint file of a previous run that should be resumed.
+var ResumeFile string
+
+// RetryBaseDelay is the delay before the first retry of a failed creation request, which doubles with every subsequent
+// retry.
+var RetryBaseDelay time.Duration
+
+// RetryMaxDelay is the maximum delay between two retries of a failed creation request.
+var RetryMaxDelay time.Duration
+
+// RhcConnectionsPerTenant is the distribution of the number of rhcConnections the program will create for each source.
+var RhcConnectionsPerTenant CountDistribution
+
+// Seed makes the generated data deterministic when it is not empty: the same seed and the same source types catalogue
+// produce the same tenants and resources on every run.
+var Seed string
+
+// SourceTypeCounts holds the exact number of sources, by source type name, to create for each tenant. When it is not
+// empty, it takes precedence over both "SourcesPerTenant" and "SourceTypeWeights".
+var SourceTypeCounts map[string]int
+
+// SourceTypeWeights holds the relative weights, by source type name, used when picking a random source type for a new
+// source. The source types without a weight have a weight of 1.
+var SourceTypeWeights map[string]float64
+
+// SourceTypesExportFile is the path to the file the loaded source types and application types get exported to, in the
+// same format as the "SourceTypesFile". When empty, the catalogue is not exported.
+var SourceTypesExportFile string
+
+// SourceTypesFile is the path to a local JSON file with the source types and application types to use, instead of
+// fetching them from the back end.
+var SourceTypesFile string
 
 // SourcesApiHealthUrl is the full URL for the "health" endpoint of the sources-api back end.
 var SourcesApiHealthUrl string
@@ -57,12 +213,40 @@ var SourcesApiHealthUrl string
 // SourcesApiUrl is the URL for the sources-api back end, including the "v31Path".
 var SourcesApiUrl string
 
-// SourcesPerTenant is the number of sources the program will create for each tenant.
-var SourcesPerTenant int
+// SourcesPerTenant is the distribution of the number of sources the program will create for each tenant.
+var SourcesPerTenant CountDistribution
+
+// StrictCatalogue makes the program exit when the source types and application types catalogue has integrity issues,
+// such as application types which support source types that don't exist, instead of just warning about them.
+var StrictCatalogue bool
+
+// TenantInitializationTimeout is the timeout for initializing all the tenants.
+var TenantInitializationTimeout time.Duration
+
+// TLS settings for the connections to the back end.
+var (
+	// TlsCaBundle is the path to a PEM file with the certificate authorities to trust, on top of the system ones.
+	TlsCaBundle string
+	// TlsClientCert is the path to the PEM client certificate to present to the back end.
+	TlsClientCert string
+	// TlsClientKey is the path to the PEM private key of the client certificate.
+	TlsClientKey string
+	// TlsInsecureSkipVerify disables the verification of the back end's certificate.
+	TlsInsecureSkipVerify bool
+	// TlsServerName overrides the server name sent in the SNI extension and used to verify the back end's certificate.
+	TlsServerName string
+)
 
-// Tenants holds an array of base64 XRHID objects with random OrgIds ready to be sent to the back end.
+// Tenants holds an array of base64 XRHID objects ready to be sent to the back end. It holds the tenants the user
+// specified, followed by the generated ones.
 var Tenants []string
 
+// resourceRateLimits holds the rate limits for the resource types which have a specific rate limit configured.
+var resourceRateLimits map[string]float64
+
+// resourceTimeouts holds the timeouts for the resource types which have a specific timeout configured.
+var resourceTimeouts map[string]time.Duration
+
 // URLs for the different endpoints we will be sending requests to.
 var (
 	ApplicationCreateUrl    string
@@ -75,142 +259,358 @@ var (
 )
 
 // ParseConfig grabs the URL for the Sources API instance and the parameters to create the fixtures on the database.
+// The values are taken from the configuration file first, if any, then the environment variables override them, and
+// finally the command line flags override everything else.
 func ParseConfig() {
-	// Get the log level for the logger.
-	logLevel := os.Getenv("LOG_LEVEL")
-	if logLevel == "" {
-		LogLevel = "info"
-	} else {
-		LogLevel = logLevel
+	// Get the command to run and the configuration file, if any.
+	command, configFile := parseCommandLine(os.Args[1:])
+
+	if configFile == "" {
+		configFile = os.Getenv("POPULATOR_CONFIG")
 	}
 
-	// Get the sources instance's host.
-	sourcesHost := os.Getenv("SOURCES_API_HOST")
-	if sourcesHost == "" {
-		log.Fatalf("configuration missing: Sources API host")
+	settings := Settings{
+		ApplicationsPerSource:      CountSetting(strconv.Itoa(defaultApplicationsPerSource)),
+		AuthMode:                   AuthModeIdentity,
+		AuthenticationsPerResource: CountSetting(strconv.Itoa(defaultAuthenticationsPerResource)),
+		ConcurrentRequests:         defaultConcurrentRequests,
+		DataGenerator:              "realistic",
+		DescribeFormat:             DescribeFormatTable,
+		EndpointsPerSource:         CountSetting(strconv.Itoa(defaultEndpointsPerSource)),
+		LogLevel:                   "info",
+		MaxRetries:                 defaultMaxRetries,
+		Mode:                       ModePopulate,
+		NumberOfTenants:            unsetNumberOfTenants,
+		RetryBaseDelay:             defaultRetryBaseDelay.String(),
+		RetryMaxDelay:              defaultRetryMaxDelay.String(),
+		RhcConnectionsPerTenant:    CountSetting(strconv.Itoa(defaultRhcConnectionsPerTenant)),
+		SourcesPerTenant:           CountSetting(strconv.Itoa(defaultSourcesPerTenant)),
+		TenancyMode:                TenancyModeAccountNumber,
+		Timeouts: TimeoutSettings{
+			Default:     defaultRequestTimeout.String(),
+			Catalogue:   defaultShortTimeout.String(),
+			HealthCheck: defaultShortTimeout.String(),
+			TenantInit:  defaultShortTimeout.String(),
+		},
 	}
 
-	// Get the sources instance's port.
-	sourcesPort := os.Getenv("SOURCES_API_PORT")
-	if sourcesPort == "" || sourcesPort == "0" {
-		log.Fatalf("configuration missing: Sources API port")
+	if configFile != "" {
+		if err := readConfigFile(configFile, &settings); err != nil {
+			log.Fatalf(`could not read the configuration file "%s": %s`, configFile, err)
+		}
 	}
 
-	// Build the URL.
-	SourcesApiHealthUrl = fmt.Sprintf("%s:%s/health", sourcesHost, sourcesPort)
-	SourcesApiUrl = fmt.Sprintf("%s:%s/%s", sourcesHost, sourcesPort, sourcesV31Path)
+	// Override the settings with the environment variables.
+	getEnvString("LOG_LEVEL", &settings.LogLevel)
+	getEnvString("MODE", &settings.Mode)
+	getEnvString("DESCRIBE_FORMAT", &settings.DescribeFormat)
+	getEnvString("METRICS_ADDRESS", &settings.MetricsAddress)
+	getEnvString("MANIFEST_FILE", &settings.ManifestFile)
+	getEnvString("CHECKPOINT_FILE", &settings.CheckpointFile)
+	getEnvString("RESUME_FILE", &settings.ResumeFile)
+	getEnvBool("DRY_RUN", &settings.DryRun, "dry run flag")
+	getEnvBool("STRICT_CATALOGUE", &settings.StrictCatalogue, "strict catalogue flag")
+	getEnvString("DRY_RUN_OUTPUT_FILE", &settings.DryRunOutputFile)
+	getEnvString("SOURCE_TYPES_FILE", &settings.SourceTypesFile)
+	getEnvString("SOURCE_TYPES_EXPORT_FILE", &settings.SourceTypesExportFile)
+	getEnvString("SOURCES_API_HOST", &settings.SourcesApiHost)
+	getEnvInt("SOURCES_API_PORT", &settings.SourcesApiPort, "Sources API port")
+	getEnvString("AUTH_MODE", &settings.AuthMode)
+	getEnvString("SOURCES_PSK", &settings.Psk)
+	getEnvString("TLS_CA_BUNDLE", &settings.Tls.CaBundle)
+	getEnvString("TLS_CLIENT_CERT", &settings.Tls.ClientCert)
+	getEnvString("TLS_CLIENT_KEY", &settings.Tls.ClientKey)
+	getEnvBool("TLS_INSECURE_SKIP_VERIFY", &settings.Tls.InsecureSkipVerify, "TLS insecure skip verify flag")
+	getEnvString("TLS_SERVER_NAME", &settings.Tls.ServerName)
+	getEnvInt("CONCURRENT_REQUESTS", &settings.ConcurrentRequests, "maximum concurrent requests for the program")
+	getEnvInt("NUMBER_OF_TENANTS", &settings.NumberOfTenants, "number of tenants to create")
+	getEnvString("EXISTING_TENANTS_FILE", &settings.ExistingTenantsFile)
+	getEnvString("TENANCY_MODE", &settings.TenancyMode)
+	getEnvString("SOURCES_PER_TENANT", (*string)(&settings.SourcesPerTenant))
+	getEnvString("RHC_CONNECTIONS_PER_TENANT", (*string)(&settings.RhcConnectionsPerTenant))
+	getEnvString("ENDPOINTS_PER_SOURCE", (*string)(&settings.EndpointsPerSource))
+	getEnvString("AUTHENTICATIONS_PER_RESOURCE", (*string)(&settings.AuthenticationsPerResource))
+	getEnvString("APPLICATIONS_PER_SOURCE", (*string)(&settings.ApplicationsPerSource))
+
+	if weights := os.Getenv("SOURCE_TYPE_WEIGHTS"); weights != "" {
+		sourceTypeWeights, err := parseSourceTypeWeights(weights)
+		if err != nil {
+			log.Fatalf(`could not parse the source type weights: %s`, err)
+		}
 
-	// Get the maximum number of concurrent requests.
-	concurrentRequests := os.Getenv("CONCURRENT_REQUESTS")
-	if concurrentRequests == "" {
-		ConcurrentRequests = make(chan struct{}, defaultConcurrentRequests)
-	} else {
-		tmp, err := strconv.Atoi(concurrentRequests)
+		settings.SourceTypeWeights = sourceTypeWeights
+	}
+
+	if counts := os.Getenv("SOURCE_TYPE_COUNTS"); counts != "" {
+		sourceTypeCounts, err := parseSourceTypeCounts(counts)
 		if err != nil {
-			log.Fatalf(`could not parse the maximum concurrent requests for the program: %s`, err)
+			log.Fatalf(`could not parse the source type counts: %s`, err)
 		}
 
-		if tmp < 1 {
-			log.Printf(`warning: you specified less than 1 concurrent requests: %d. Defaulting to %d`, tmp, defaultConcurrentRequests)
-			ConcurrentRequests = make(chan struct{}, defaultConcurrentRequests)
-		} else {
-			ConcurrentRequests = make(chan struct{}, tmp)
+		settings.SourceTypeCounts = sourceTypeCounts
+	}
+	getEnvList("INCLUDE_SOURCE_TYPES", &settings.IncludeSourceTypes)
+	getEnvList("EXCLUDE_SOURCE_TYPES", &settings.ExcludeSourceTypes)
+	getEnvList("INCLUDE_APPLICATION_TYPES", &settings.IncludeApplicationTypes)
+	getEnvList("EXCLUDE_APPLICATION_TYPES", &settings.ExcludeApplicationTypes)
+	getEnvString("REQUEST_TIMEOUT", &settings.Timeouts.Default)
+	getEnvInt("MAX_RETRIES", &settings.MaxRetries, "maximum number of retries")
+	getEnvString("RETRY_BASE_DELAY", &settings.RetryBaseDelay)
+	getEnvString("RETRY_MAX_DELAY", &settings.RetryMaxDelay)
+	getEnvString("SEED", &settings.Seed)
+	getEnvString("DATA_GENERATOR", &settings.DataGenerator)
+	getEnvFloat("EDGE_CASE_RATE", &settings.EdgeCaseRate, "edge case rate")
+	getEnvFloat("RATE_LIMIT", &settings.RateLimits.RequestsPerSecond, "rate limit")
+	getEnvFloat("RATE_LIMIT_RAMP_UP_FROM", &settings.RateLimits.RampUpFrom, "rate limit at the beginning of the ramp up")
+	getEnvString("RATE_LIMIT_RAMP_UP_DURATION", &settings.RateLimits.RampUpDuration)
+
+	// The command given in the command line takes precedence over the configured mode.
+	switch command {
+	case "":
+	case commandPlan:
+		settings.Mode = ModePopulate
+		settings.DryRun = true
+	default:
+		settings.Mode = command
+	}
+
+	// Get the tenants specified by the user, which come as a comma separated list of base64 encoded XRHIDs, just like
+	// the ones the program prints at the end of a populate run.
+	tenants := os.Getenv("TENANTS")
+	if tenants != "" {
+		settings.Tenants = nil
+		for _, tenant := range strings.Split(tenants, ",") {
+			tenant = strings.TrimSpace(tenant)
+			if tenant == "" {
+				continue
+			}
+
+			settings.Tenants = append(settings.Tenants, tenant)
 		}
 	}
 
-	// Get the number of tenants to be created.
-	numberTenants := os.Getenv("NUMBER_OF_TENANTS")
-	var tenantsNumber int
-	if numberTenants == "" {
-		tenantsNumber = defaultTenants
-	} else {
-		tmp, err := strconv.Atoi(numberTenants)
+	// Get the existing tenants specified by their account numbers and org IDs.
+	if existingTenants := os.Getenv("EXISTING_TENANTS"); existingTenants != "" {
+		parsed, err := parseExistingTenants(existingTenants)
 		if err != nil {
-			log.Fatalf(`could not parse the number of tenants to create: %s`, err)
+			log.Fatalf(`could not parse the existing tenants: %s`, err)
 		}
 
-		tenantsNumber = tmp
+		settings.ExistingTenants = parsed
 	}
 
-	// Generate the XRHID objects with random OrgIds.
-	var xRhIds []identity.XRHID
-	for i := 0; i < tenantsNumber; i++ {
-		id, err := uuid.NewUUID()
-		if err != nil {
-			log.Fatalf(`could not generate UUID for the default tenants: %s`, err)
+	// Get the log level for the logger.
+	LogLevel = settings.LogLevel
+
+	// Get the mode the program will be run in.
+	switch settings.Mode {
+	case ModeCleanup, ModeDescribeTypes, ModePopulate, ModeVerify:
+		Mode = settings.Mode
+	default:
+		log.Fatalf(`invalid mode "%s". Valid modes are "%s", "%s", "%s" and "%s"`, settings.Mode, ModePopulate, ModeVerify, ModeCleanup, ModeDescribeTypes)
+	}
+
+	// Get the format of the compatibility matrix.
+	switch settings.DescribeFormat {
+	case DescribeFormatCsv, DescribeFormatJson, DescribeFormatTable:
+		DescribeFormat = settings.DescribeFormat
+	default:
+		log.Fatalf(`invalid describe format "%s". Valid formats are "%s", "%s" and "%s"`, settings.DescribeFormat, DescribeFormatTable, DescribeFormatJson, DescribeFormatCsv)
+	}
+
+	// Get the address of the metrics listener.
+	MetricsAddress = settings.MetricsAddress
+
+	// Get the paths of the manifest and checkpoint files, and of the checkpoint file of the run to resume, if any.
+	ManifestFile = settings.ManifestFile
+	CheckpointFile = settings.CheckpointFile
+	ResumeFile = settings.ResumeFile
+
+	// Get whether this is a dry run or not, and the path of the source types file.
+	DryRun = settings.DryRun
+	DryRunOutputFile = settings.DryRunOutputFile
+	SourceTypesFile = settings.SourceTypesFile
+	SourceTypesExportFile = settings.SourceTypesExportFile
+	StrictCatalogue = settings.StrictCatalogue
+
+	// Set the seed before generating anything random, tenants included.
+	Seed = settings.Seed
+	random.SetSeed(Seed)
+
+	// Get how the resources' field values are generated. The generator's name is validated once the generators are
+	// set up, since more of them can be registered.
+	DataGenerator = settings.DataGenerator
+	if settings.EdgeCaseRate < 0 || settings.EdgeCaseRate > 1 {
+		log.Fatalf(`invalid edge case rate: %g. It must be between 0 and 1`, settings.EdgeCaseRate)
+	}
+	EdgeCaseRate = settings.EdgeCaseRate
+
+	if DryRun {
+		if Mode != ModePopulate {
+			log.Fatalf(`the dry run is only supported in the "%s" mode`, ModePopulate)
+		}
+
+		if SourceTypesFile == "" {
+			log.Fatalf(`configuration missing: the dry run requires a source types file, since the back end is not contacted`)
 		}
 
-		xRhIds = append(
-			xRhIds,
-			identity.XRHID{
-				Identity: identity.Identity{
-					AccountNumber: id.String(),
-				},
-			},
-		)
+		// The planned resources get fake IDs, which must not end up in a manifest or a checkpoint that a later cleanup
+		// or resumed run would trust.
+		if ManifestFile != "" || CheckpointFile != "" || ResumeFile != "" {
+			log.Fatalf(`the dry run cannot record a manifest, write a checkpoint or resume a run, since the planned resources don't exist`)
+		}
 	}
 
-	// Transform the XRHID objects to base64 encoded strings ready to be used in the "x-rh-identity" headers.
-	for _, xRhId := range xRhIds {
-		result, err := json.Marshal(xRhId)
-		if err != nil {
-			log.Fatalf(`could not JSON encode the XRHID object: %s`, err)
+	// Get the sources instance's host.
+	if settings.SourcesApiHost == "" {
+		log.Fatalf("configuration missing: Sources API host")
+	}
+
+	// Get the sources instance's port.
+	if settings.SourcesApiPort == 0 {
+		log.Fatalf("configuration missing: Sources API port")
+	}
+
+	// Get how the requests get authenticated.
+	switch settings.AuthMode {
+	case AuthModeIdentity:
+	case AuthModePsk:
+		if settings.Psk == "" {
+			log.Fatalf(`configuration missing: the "%s" authentication mode requires the pre shared key`, AuthModePsk)
 		}
+	default:
+		log.Fatalf(`invalid authentication mode "%s". Valid modes are "%s" and "%s"`, settings.AuthMode, AuthModeIdentity, AuthModePsk)
+	}
+	AuthMode = settings.AuthMode
+	Psk = settings.Psk
 
-		Tenants = append(Tenants, base64.StdEncoding.EncodeToString(result))
+	// Get the TLS settings. The client certificate and its key go together.
+	if (settings.Tls.ClientCert == "") != (settings.Tls.ClientKey == "") {
+		log.Fatalf(`configuration missing: both the TLS client certificate and its key must be specified`)
 	}
+	TlsCaBundle = settings.Tls.CaBundle
+	TlsClientCert = settings.Tls.ClientCert
+	TlsClientKey = settings.Tls.ClientKey
+	TlsInsecureSkipVerify = settings.Tls.InsecureSkipVerify
+	TlsServerName = settings.Tls.ServerName
 
-	// Get the sources to create per tenant.
-	sourcesPerTenant := os.Getenv("SOURCES_PER_TENANT")
-	if sourcesPerTenant == "" {
-		SourcesPerTenant = defaultSourcesPerTenant
-	} else {
-		tmp, err := strconv.Atoi(sourcesPerTenant)
+	// Build the URL.
+	SourcesApiHealthUrl = fmt.Sprintf("%s:%d/health", settings.SourcesApiHost, settings.SourcesApiPort)
+	SourcesApiUrl = fmt.Sprintf("%s:%d/%s", settings.SourcesApiHost, settings.SourcesApiPort, sourcesV31Path)
+
+	// Get the maximum number of concurrent requests.
+	if settings.ConcurrentRequests < 1 {
+		log.Printf(`warning: you specified less than 1 concurrent requests: %d. Defaulting to %d`, settings.ConcurrentRequests, defaultConcurrentRequests)
+		settings.ConcurrentRequests = defaultConcurrentRequests
+	}
+	ConcurrentRequests = make(chan struct{}, settings.ConcurrentRequests)
+
+	// Get the timeouts for the requests.
+	parseTimeouts(settings.Timeouts)
+
+	// Get the retry settings for the failed creation requests.
+	if settings.MaxRetries < 0 {
+		log.Fatalf(`invalid maximum number of retries: %d. It cannot be negative`, settings.MaxRetries)
+	}
+	MaxRetries = settings.MaxRetries
+	RetryBaseDelay = parseDuration(settings.RetryBaseDelay, "retry base delay")
+	RetryMaxDelay = parseDuration(settings.RetryMaxDelay, "retry maximum delay")
+
+	// Get the rate limits for the creation requests.
+	parseRateLimits(settings.RateLimits)
+
+	// Get the tenants specified by the user: the base64 encoded identities first, and then the ones built from the
+	// existing tenants' identifiers.
+	Tenants = settings.Tenants
+
+	existingTenants := settings.ExistingTenants
+	if settings.ExistingTenantsFile != "" {
+		fromFile, err := readExistingTenantsFile(settings.ExistingTenantsFile)
 		if err != nil {
-			log.Fatalf(`could not parse the number of sources to create per tenant: %s`, err)
+			log.Fatalf(`could not read the existing tenants file "%s": %s`, settings.ExistingTenantsFile, err)
 		}
 
-		SourcesPerTenant = tmp
+		existingTenants = append(existingTenants, fromFile...)
 	}
 
-	// Get the rhcConnections to create per tenant.
-	rhcConnectionsPerTenant := os.Getenv("RHC_CONNECTIONS_PER_TENANT")
-	if rhcConnectionsPerTenant == "" {
-		RhcConnectionsPerTenant = defaultRhcConnectionsPerTenant
-	} else {
-		tmp, err := strconv.Atoi(rhcConnectionsPerTenant)
+	for _, existingTenant := range existingTenants {
+		tenant, err := encodeIdentity(existingTenant.AccountNumber, existingTenant.OrgId)
 		if err != nil {
-			log.Fatalf(`could not parse the number of rhc connections to create per tenant: %s`, err)
+			log.Fatalf(`could not build the identity of the existing tenant: %s`, err)
 		}
 
-		RhcConnectionsPerTenant = tmp
+		Tenants = append(Tenants, tenant)
 	}
 
-	// Get the endpoints to create per source_types_db.
-	endpointsPerSource := os.Getenv("ENDPOINTS_PER_SOURCE")
-	if endpointsPerSource == "" {
-		EndpointsPerSource = defaultEndpointsPerSource
-	} else {
-		tmp, err := strconv.Atoi(endpointsPerSource)
+	if (Mode == ModeCleanup || Mode == ModeVerify) && len(Tenants) == 0 && ManifestFile == "" {
+		log.Fatalf(`configuration missing: the %s mode requires either the tenants or the manifest file to be specified`, Mode)
+	}
+
+	// Generate the random tenants, which are only used for populating the database. By default, they are only
+	// generated when the user didn't specify any tenants, but they can be mixed with the user's tenants by
+	// explicitly specifying how many to generate.
+	if Mode == ModePopulate {
+		if settings.NumberOfTenants == unsetNumberOfTenants {
+			settings.NumberOfTenants = 0
+			if len(Tenants) == 0 {
+				settings.NumberOfTenants = defaultTenants
+			}
+		}
+
+		if settings.NumberOfTenants < 0 {
+			log.Fatalf(`invalid number of tenants: %d. It cannot be negative`, settings.NumberOfTenants)
+		}
+
+		switch settings.TenancyMode {
+		case TenancyModeAccountNumber, TenancyModeBoth, TenancyModeMixed, TenancyModeOrgId:
+		default:
+			log.Fatalf(`invalid tenancy mode "%s". Valid modes are "%s", "%s", "%s" and "%s"`, settings.TenancyMode, TenancyModeAccountNumber, TenancyModeOrgId, TenancyModeBoth, TenancyModeMixed)
+		}
+
+		generatedTenants, err := generateTenants(settings.NumberOfTenants, settings.TenancyMode)
 		if err != nil {
-			log.Fatalf(`could not parse the number of endpoints to create per source_types_db: %s`, err)
+			log.Fatalf(`could not generate the tenants: %s`, err)
 		}
 
-		EndpointsPerSource = tmp
+		Tenants = append(Tenants, generatedTenants...)
 	}
 
-	authenticationsPerResource := os.Getenv("AUTHENTICATIONS_PER_RESOURCE")
-	if authenticationsPerResource == "" {
-		AuthenticationsPerResource = defaultAuthenticationsPerResource
-	} else {
-		tmp, err := strconv.Atoi(authenticationsPerResource)
-		if err != nil {
-			log.Fatalf(`could not parse the number of authentications to create per resource: %s`, err)
+	// Get the distributions of the number of resources to create.
+	SourcesPerTenant = getCountDistribution(settings.SourcesPerTenant, "sources per tenant")
+	RhcConnectionsPerTenant = getCountDistribution(settings.RhcConnectionsPerTenant, "rhc connections per source")
+	EndpointsPerSource = getCountDistribution(settings.EndpointsPerSource, "endpoints per source")
+	AuthenticationsPerResource = getCountDistribution(settings.AuthenticationsPerResource, "authentications per resource")
+	ApplicationsPerSource = getCountDistribution(settings.ApplicationsPerSource, "applications per source")
+
+	// Get the weights for picking the source types.
+	for name, weight := range settings.SourceTypeWeights {
+		if weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			log.Fatalf(`invalid weight for the source type "%s": the weight must be a finite, non negative number`, name)
 		}
+	}
+	SourceTypeWeights = settings.SourceTypeWeights
+
+	// Get the exact number of sources per source type, if any.
+	for name, count := range settings.SourceTypeCounts {
+		if count < 0 {
+			log.Fatalf(`invalid count for the source type "%s": the count cannot be negative`, name)
+		}
+	}
+	SourceTypeCounts = settings.SourceTypeCounts
 
-		AuthenticationsPerResource = tmp
+	// Get the filters for the source types and application types. The source types which are excluded by default are
+	// added to the ones the user specified, so that specifying a few more doesn't bring the default ones back.
+	for _, name := range defaultExcludedSourceTypes {
+		if !containsString(settings.ExcludeSourceTypes, name) {
+			settings.ExcludeSourceTypes = append(settings.ExcludeSourceTypes, name)
+		}
 	}
 
+	IncludeSourceTypes = settings.IncludeSourceTypes
+	ExcludeSourceTypes = settings.ExcludeSourceTypes
+	IncludeApplicationTypes = settings.IncludeApplicationTypes
+	ExcludeApplicationTypes = settings.ExcludeApplicationTypes
+
 	// Initialize the endpoint URLs we will be sending the requests to.
 	ApplicationCreateUrl = fmt.Sprintf("%s/applications", SourcesApiUrl)
 	ApplicationTypesUrl = fmt.Sprintf("%s/application_types", SourcesApiUrl)
@@ -219,4 +619,191 @@ func ParseConfig() {
 	RhcConnectionCreateUrl = fmt.Sprintf("%s/rhc_connections", SourcesApiUrl)
 	SourceCreateUrl = fmt.Sprintf("%s/sources", SourcesApiUrl)
 	SourceTypesUrl = fmt.Sprintf("%s/source_types", SourcesApiUrl)
+
+	// Print the effective configuration, so that it is clear which values were picked from where. We don't use the
+	// logger since it hasn't been initialized yet, and we don't want the standard output to be mixed with the results.
+	// The pre shared key is a secret, so it doesn't get printed.
+	if settings.Psk != "" {
+		settings.Psk = "redacted"
+	}
+
+	effectiveSettings, err := json.Marshal(settings)
+	if err != nil {
+		log.Printf(`warning: could not JSON encode the effective configuration: %s`, err)
+	} else {
+		log.Printf(`effective configuration: %s`, effectiveSettings)
+	}
+}
+
+// GetTimeout returns the timeout for the requests of the given resource type.
+func GetTimeout(resourceType string) time.Duration {
+	if timeout, ok := resourceTimeouts[resourceType]; ok {
+		return timeout
+	}
+
+	return RequestTimeout
+}
+
+// GetRateLimit returns the rate limit for the creation requests of the given resource type. Zero means that the
+// resource type doesn't have a specific rate limit.
+func GetRateLimit(resourceType string) float64 {
+	return resourceRateLimits[resourceType]
+}
+
+// parseRateLimits parses the rate limits from the settings, and exits the program if any of them is not valid.
+func parseRateLimits(rateLimits RateLimitSettings) {
+	if rateLimits.RequestsPerSecond < 0 {
+		log.Fatalf(`invalid rate limit: %g. The rate limit cannot be negative`, rateLimits.RequestsPerSecond)
+	}
+	RateLimit = rateLimits.RequestsPerSecond
+
+	resourceRateLimits = make(map[string]float64)
+	for resourceType, rateLimit := range map[string]float64{
+		"application":    rateLimits.Application,
+		"authentication": rateLimits.Authentication,
+		"endpoint":       rateLimits.Endpoint,
+		"rhcConnection":  rateLimits.RhcConnection,
+		"source":         rateLimits.Source,
+	} {
+		if rateLimit < 0 {
+			log.Fatalf(`invalid %s rate limit: %g. The rate limit cannot be negative`, resourceType, rateLimit)
+		}
+
+		if rateLimit > 0 {
+			resourceRateLimits[resourceType] = rateLimit
+		}
+	}
+
+	if rateLimits.RampUpDuration == "" {
+		return
+	}
+
+	if RateLimit == 0 {
+		log.Fatalf(`configuration missing: the rate limit ramp up requires an overall rate limit to ramp up to`)
+	}
+
+	if rateLimits.RampUpFrom <= 0 {
+		log.Fatalf(`invalid rate limit to ramp up from: %g. It must be greater than zero`, rateLimits.RampUpFrom)
+	}
+
+	RateLimitRampUpFrom = rateLimits.RampUpFrom
+	RateLimitRampUpDuration = parseDuration(rateLimits.RampUpDuration, "rate limit ramp up duration")
+}
+
+// parseTimeouts parses the timeouts from the settings. The resource timeouts that were not specified default to the
+// general request timeout.
+func parseTimeouts(timeouts TimeoutSettings) {
+	RequestTimeout = parseDuration(timeouts.Default, "default request timeout")
+	CatalogueTimeout = parseDuration(timeouts.Catalogue, "catalogue timeout")
+	HealthCheckTimeout = parseDuration(timeouts.HealthCheck, "health check timeout")
+	TenantInitializationTimeout = parseDuration(timeouts.TenantInit, "tenant initialization timeout")
+
+	resourceTimeouts = make(map[string]time.Duration)
+	for resourceType, timeout := range map[string]string{
+		"application":    timeouts.Application,
+		"authentication": timeouts.Authentication,
+		"endpoint":       timeouts.Endpoint,
+		"rhcConnection":  timeouts.RhcConnection,
+		"source":         timeouts.Source,
+	} {
+		if timeout != "" {
+			resourceTimeouts[resourceType] = parseDuration(timeout, fmt.Sprintf("%s timeout", resourceType))
+		}
+	}
+}
+
+// containsString returns true if the given value is in the list.
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCountDistribution parses the given count setting, and it exits the program if it is not valid.
+func getCountDistribution(setting CountSetting, description string) CountDistribution {
+	distribution, err := parseCountDistribution(string(setting))
+	if err != nil {
+		log.Fatalf(`could not parse the number of %s: %s`, description, err)
+	}
+
+	return distribution
+}
+
+// parseDuration parses the given duration, and exits the program if it is not valid.
+func parseDuration(duration string, description string) time.Duration {
+	tmp, err := time.ParseDuration(duration)
+	if err != nil {
+		log.Fatalf(`could not parse the %s: %s`, description, err)
+	}
+
+	if tmp <= 0 {
+		log.Fatalf(`invalid %s: %s. It must be greater than zero`, description, duration)
+	}
+
+	return tmp
+}
+
+// getEnvBool overrides the given value with the boolean from the given environment variable, if it is set.
+func getEnvBool(name string, value *bool, description string) {
+	if env := os.Getenv(name); env != "" {
+		tmp, err := strconv.ParseBool(env)
+		if err != nil {
+			log.Fatalf(`could not parse the %s: %s`, description, err)
+		}
+
+		*value = tmp
+	}
+}
+
+// getEnvFloat overrides the given value with the floating point number from the given environment variable, if it is
+// set.
+func getEnvFloat(name string, value *float64, description string) {
+	if env := os.Getenv(name); env != "" {
+		tmp, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			log.Fatalf(`could not parse the %s: %s`, description, err)
+		}
+
+		*value = tmp
+	}
+}
+
+// getEnvInt overrides the given value with the integer from the given environment variable, if it is set.
+func getEnvInt(name string, value *int, description string) {
+	if env := os.Getenv(name); env != "" {
+		tmp, err := strconv.Atoi(env)
+		if err != nil {
+			log.Fatalf(`could not parse the %s: %s`, description, err)
+		}
+
+		*value = tmp
+	}
+}
+
+// getEnvList overrides the given list with the given environment variable's comma separated values, if it is set. Unlike
+// the rest of the variables, an empty value is taken into account, and it empties the list.
+func getEnvList(name string, value *[]string) {
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+
+	*value = []string{}
+	for _, entry := range strings.Split(env, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			*value = append(*value, entry)
+		}
+	}
+}
+
+// getEnvString overrides the given value with the given environment variable, if it is set.
+func getEnvString(name string, value *string) {
+	if env := os.Getenv(name); env != "" {
+		*value = env
+	}
 }
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseSourceTypeWeights parses a comma separated list of "source_type_name:weight" pairs.
+func parseSourceTypeWeights(list string) (map[string]float64, error) {
+	pairs, err := splitSourceTypePairs(list)
+	if err != nil {
+		return nil, err
+	}
+
+	weights := make(map[string]float64, len(pairs))
+	for name, value := range pairs {
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid weight "%s" for the source type "%s": %w`, value, name, err)
+		}
+
+		weights[name] = weight
+	}
+
+	return weights, nil
+}
+
+// parseSourceTypeCounts parses a comma separated list of "source_type_name:count" pairs.
+func parseSourceTypeCounts(list string) (map[string]int, error) {
+	pairs, err := splitSourceTypePairs(list)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(pairs))
+	for name, value := range pairs {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid count "%s" for the source type "%s": %w`, value, name, err)
+		}
+
+		counts[name] = count
+	}
+
+	return counts, nil
+}
+
+// splitSourceTypePairs splits a comma separated list of "source_type_name:value" pairs into a map.
+func splitSourceTypePairs(list string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf(`invalid entry "%s": it must be a "source_type_name:value" pair`, entry)
+		}
+
+		name := strings.TrimSpace(parts[0])
+		if _, ok := pairs[name]; ok {
+			return nil, fmt.Errorf(`the source type "%s" is specified more than once`, name)
+		}
+
+		pairs[name] = strings.TrimSpace(parts[1])
+	}
+
+	return pairs, nil
+}
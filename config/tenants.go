@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/MikelAlejoBR/sources-database-populator/random"
+	"github.com/redhatinsights/platform-go-middlewares/identity"
+)
+
+// Tenancy modes which specify the identifiers the generated tenants get.
+const (
+	TenancyModeAccountNumber = "account_number"
+	TenancyModeBoth          = "both"
+	TenancyModeMixed         = "mixed"
+	TenancyModeOrgId         = "org_id"
+)
+
+// TenantIdentifiers holds the identifiers of a tenant. Either of them can be empty.
+type TenantIdentifiers struct {
+	AccountNumber string `json:"account_number" yaml:"account_number"`
+	OrgId         string `json:"org_id" yaml:"org_id"`
+}
+
+// parseExistingTenants parses a comma separated list of "account_number:org_id" pairs, in which either of the
+// identifiers can be left empty. An entry without a colon is taken as an account number.
+func parseExistingTenants(list string) ([]TenantIdentifiers, error) {
+	var tenants []TenantIdentifiers
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var accountNumber, orgId string
+		if parts := strings.SplitN(entry, ":", 2); len(parts) == 2 {
+			accountNumber, orgId = parts[0], parts[1]
+		} else {
+			accountNumber = entry
+		}
+
+		tenant, err := newExistingTenant(accountNumber, orgId)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid tenant "%s": %w`, entry, err)
+		}
+
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, nil
+}
+
+// readExistingTenantsFile reads the existing tenants from the given CSV file, which has an "account_number,org_id"
+// line per tenant. The lines starting with "#" and the "account_number,org_id" header are skipped.
+func readExistingTenantsFile(path string) ([]TenantIdentifiers, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var tenants []TenantIdentifiers
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return tenants, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if len(record) > 2 {
+			return nil, fmt.Errorf(`line %d: expected at most two columns, got %d`, line, len(record))
+		}
+
+		accountNumber := strings.TrimSpace(record[0])
+
+		var orgId string
+		if len(record) == 2 {
+			orgId = strings.TrimSpace(record[1])
+		}
+
+		if accountNumber == "account_number" && orgId == "org_id" {
+			continue
+		}
+
+		tenant, err := newExistingTenant(accountNumber, orgId)
+		if err != nil {
+			return nil, fmt.Errorf(`line %d: %w`, line, err)
+		}
+
+		tenants = append(tenants, tenant)
+	}
+}
+
+// newExistingTenant creates an existing tenant from the given identifiers, making sure that at least one of them was
+// given.
+func newExistingTenant(accountNumber string, orgId string) (TenantIdentifiers, error) {
+	accountNumber = strings.TrimSpace(accountNumber)
+	orgId = strings.TrimSpace(orgId)
+
+	if accountNumber == "" && orgId == "" {
+		return TenantIdentifiers{}, fmt.Errorf(`either the account number or the org ID must be specified`)
+	}
+
+	return TenantIdentifiers{AccountNumber: accountNumber, OrgId: orgId}, nil
+}
+
+// encodeIdentity builds the base64 encoded XRHID, ready to be used in the "x-rh-identity" header, for the tenant with
+// the given identifiers. The org ID is set both in the identity and in its "internal" object, just like the platform
+// does.
+func encodeIdentity(accountNumber string, orgId string) (string, error) {
+	xRhId := identity.XRHID{
+		Identity: identity.Identity{
+			AccountNumber: accountNumber,
+			OrgID:         orgId,
+			Internal: identity.Internal{
+				OrgID: orgId,
+			},
+		},
+	}
+
+	result, err := json.Marshal(xRhId)
+	if err != nil {
+		return "", fmt.Errorf(`could not JSON encode the XRHID object: %w`, err)
+	}
+
+	return base64.StdEncoding.EncodeToString(result), nil
+}
+
+// GetTenantIdentifiers decodes the given base64 encoded XRHID and returns the tenant's identifiers.
+func GetTenantIdentifiers(tenant string) (TenantIdentifiers, error) {
+	decoded, err := base64.StdEncoding.DecodeString(tenant)
+	if err != nil {
+		return TenantIdentifiers{}, fmt.Errorf(`could not decode the base64 identity: %w`, err)
+	}
+
+	var xRhId identity.XRHID
+	if err := json.Unmarshal(decoded, &xRhId); err != nil {
+		return TenantIdentifiers{}, fmt.Errorf(`could not unmarshal the identity: %w`, err)
+	}
+
+	orgId := xRhId.Identity.OrgID
+	if orgId == "" {
+		orgId = xRhId.Identity.Internal.OrgID
+	}
+
+	return TenantIdentifiers{AccountNumber: xRhId.Identity.AccountNumber, OrgId: orgId}, nil
+}
+
+// generateTenants generates the given number of tenants with random identifiers. Which identifiers the tenants get
+// depends on the given tenancy mode. In the "mixed" mode the generated tenants take turns in getting only an account
+// number, only an org ID, or both.
+func generateTenants(count int, tenancyMode string) ([]string, error) {
+	var tenants []string
+	for i := 0; i < count; i++ {
+		mode := tenancyMode
+		if mode == TenancyModeMixed {
+			mode = []string{TenancyModeAccountNumber, TenancyModeOrgId, TenancyModeBoth}[i%3]
+		}
+
+		// The tenants are generated by their position, so that the same seed always generates the same tenants.
+		r := random.New(fmt.Sprintf("tenant/%d", i))
+
+		var accountNumber, orgId string
+		if mode == TenancyModeAccountNumber || mode == TenancyModeBoth {
+			id, err := random.NewUUID(r)
+			if err != nil {
+				return nil, fmt.Errorf(`could not generate UUID for the tenant's account number: %w`, err)
+			}
+
+			accountNumber = id.String()
+		}
+
+		if mode == TenancyModeOrgId || mode == TenancyModeBoth {
+			id, err := random.NewUUID(r)
+			if err != nil {
+				return nil, fmt.Errorf(`could not generate UUID for the tenant's org ID: %w`, err)
+			}
+
+			orgId = id.String()
+		}
+
+		tenant, err := encodeIdentity(accountNumber, orgId)
+		if err != nil {
+			return nil, err
+		}
+
+		tenants = append(tenants, tenant)
+	}
+
+	return tenants, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"github.com/MikelAlejoBR/sources-database-populator/source_types_db"
+	"go.uber.org/zap"
+)
+
+// Anomalies of the catalogue which the compatibility matrix highlights.
+const (
+	anomalyApplicationWithoutAuthentications = "the application type has no authentication types for this source type"
+	anomalyDanglingSourceType                = "the source type doesn't exist, but the application type supports it"
+	anomalyFilteredSourceType                = "the source type was filtered out, but the application type supports it"
+	anomalySourceWithoutAuthentications      = "the source type has no authentication types"
+	anomalyUnsupportedAuthentication         = "the source type itself doesn't support this authentication type"
+)
+
+// compatibilityRow is a row of the compatibility matrix: an authentication type which is compatible with either a
+// source type, when the application type is empty, or with an application type of the source type.
+type compatibilityRow struct {
+	SourceType         string `json:"source_type"`
+	ApplicationType    string `json:"application_type"`
+	AuthenticationType string `json:"authentication_type"`
+	Anomaly            string `json:"anomaly,omitempty"`
+}
+
+// describeTypes loads the catalogue of source types, application types and authentication types, and prints their
+// compatibility matrix in the configured format. In the strict catalogue mode, the program exits with an error after
+// printing the matrix if the catalogue has integrity issues.
+func describeTypes() {
+	initializeSourceTypesDb()
+
+	rows := getCompatibilityMatrix()
+
+	var err error
+	switch config.DescribeFormat {
+	case config.DescribeFormatCsv:
+		err = printCompatibilityCsv(rows)
+	case config.DescribeFormatJson:
+		err = printCompatibilityJson(rows)
+	default:
+		err = printCompatibilityTable(rows)
+	}
+
+	if err != nil {
+		logger.Logger.Fatalw("could not print the compatibility matrix", zap.Error(err))
+	}
+
+	exitOnStrictCatalogueIssues()
+}
+
+// getCompatibilityMatrix returns the source type × application type × authentication type matrix, sorted by source type
+// and application type names. The authentication types of the source types themselves come first, and the application
+// types which support source types that don't exist or that were filtered out come last.
+func getCompatibilityMatrix() []compatibilityRow {
+	var rows []compatibilityRow
+	for _, st := range sourceTypesDb.GetSourceTypes() {
+		supportedAuthentications := make(map[string]bool, len(st.CompatibleAuthentications))
+		for _, authType := range st.CompatibleAuthentications {
+			supportedAuthentications[authType] = true
+		}
+
+		if len(st.CompatibleAuthentications) == 0 {
+			rows = append(rows, compatibilityRow{SourceType: st.Name, Anomaly: anomalySourceWithoutAuthentications})
+		}
+
+		for _, authType := range st.CompatibleAuthentications {
+			rows = append(rows, compatibilityRow{SourceType: st.Name, AuthenticationType: authType})
+		}
+
+		appTypes := sourceTypesDb.GetApplicationTypes(st.Id)
+		sort.Slice(appTypes, func(i, j int) bool {
+			return appTypes[i].Name < appTypes[j].Name
+		})
+
+		for _, appType := range appTypes {
+			if len(appType.CompatibleAuthentications) == 0 {
+				rows = append(rows, compatibilityRow{
+					SourceType:      st.Name,
+					ApplicationType: appType.Name,
+					Anomaly:         anomalyApplicationWithoutAuthentications,
+				})
+			}
+
+			for _, authType := range appType.CompatibleAuthentications {
+				row := compatibilityRow{
+					SourceType:         st.Name,
+					ApplicationType:    appType.Name,
+					AuthenticationType: authType,
+				}
+				if !supportedAuthentications[authType] {
+					row.Anomaly = anomalyUnsupportedAuthentication
+				}
+
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	for _, issue := range sourceTypesDb.GetIssues() {
+		var anomaly string
+		switch issue.Kind {
+		case source_types_db.IssueDanglingSourceType:
+			anomaly = anomalyDanglingSourceType
+		case source_types_db.IssueFilteredSourceType:
+			anomaly = anomalyFilteredSourceType
+		default:
+			continue
+		}
+
+		rows = append(rows, compatibilityRow{
+			SourceType:      issue.SourceType,
+			ApplicationType: issue.ApplicationType,
+			Anomaly:         anomaly,
+		})
+	}
+
+	return rows
+}
+
+// printCompatibilityTable prints the compatibility matrix as an aligned table, with the anomalies marked with an
+// exclamation mark and counted at the end.
+func printCompatibilityTable(rows []compatibilityRow) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "\tSOURCE TYPE\tAPPLICATION TYPE\tAUTHENTICATION TYPE\tANOMALY")
+
+	var anomalies int
+	for _, row := range rows {
+		marker := ""
+		if row.Anomaly != "" {
+			marker = "!"
+			anomalies++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", marker, row.SourceType, orDash(row.ApplicationType), orDash(row.AuthenticationType), row.Anomaly)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nAnomalies found: %d\n", anomalies)
+
+	return nil
+}
+
+// printCompatibilityJson prints the compatibility matrix as a JSON array.
+func printCompatibilityJson(rows []compatibilityRow) error {
+	// Print an empty array rather than "null" when there are no rows.
+	if rows == nil {
+		rows = []compatibilityRow{}
+	}
+
+	result, err := json.MarshalIndent(rows, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(result))
+
+	return nil
+}
+
+// printCompatibilityCsv prints the compatibility matrix as CSV, with a header.
+func printCompatibilityCsv(rows []compatibilityRow) error {
+	w := csv.NewWriter(os.Stdout)
+
+	if err := w.Write([]string{"source_type", "application_type", "authentication_type", "anomaly"}); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := w.Write([]string{row.SourceType, row.ApplicationType, row.AuthenticationType, row.Anomaly}); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// orDash returns the given value, or a dash when it is empty, so that the empty cells are visible in the table.
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
@@ -0,0 +1,214 @@
+package fakedata
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// maxLength is the maximum length of the back end's string columns, which the "max length" edge cases fill up.
+const maxLength = 255
+
+// Generator generates the values of the resources' fields. Every value must be generated with the given random
+// generator only, so that the runs with a seed are reproducible.
+type Generator interface {
+	// SourceName returns a source name. The names must be unique within a tenant, so they should carry enough
+	// randomness to not collide with each other.
+	SourceName(r *rand.Rand) string
+	// EndpointHost returns an endpoint's host.
+	EndpointHost(r *rand.Rand) string
+	// EndpointPath returns an endpoint's path.
+	EndpointPath(r *rand.Rand) string
+	// EndpointPort returns an endpoint's port.
+	EndpointPort(r *rand.Rand) int
+	// EndpointRole returns an endpoint's role.
+	EndpointRole(r *rand.Rand) string
+	// EndpointScheme returns an endpoint's scheme.
+	EndpointScheme(r *rand.Rand) string
+	// AuthenticationName returns an authentication's name.
+	AuthenticationName(r *rand.Rand) string
+	// Username returns an authentication's username.
+	Username(r *rand.Rand) string
+	// Password returns an authentication's password.
+	Password(r *rand.Rand) string
+}
+
+// generators holds the registered generators by name.
+var generators = map[string]Generator{
+	"realistic": realisticGenerator{},
+	"uuid":      uuidGenerator{},
+}
+
+// generator is the generator chosen by the user.
+var generator Generator
+
+// Register makes the given generator available under the given name, so that it can be picked with the
+// "DATA_GENERATOR" setting. It must be called before InitializeGenerator.
+func Register(name string, g Generator) {
+	generators[name] = g
+}
+
+// InitializeGenerator picks the generator the user asked for.
+func InitializeGenerator() {
+	g, ok := generators[config.DataGenerator]
+	if !ok {
+		var names []string
+		for name := range generators {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		logger.Logger.Fatalw(
+			"unknown data generator",
+			zap.String("data_generator", config.DataGenerator),
+			zap.Strings("valid_data_generators", names),
+		)
+	}
+
+	generator = g
+}
+
+// SourceName returns a source name, which might be an edge case.
+func SourceName(r *rand.Rand) string {
+	name := generator.SourceName(r)
+
+	// The edge cases get a random suffix to keep the source names unique.
+	if isEdgeCase(r) {
+		return withSuffix(getEdgeCase(r), fmt.Sprintf(" %08x", r.Uint32()))
+	}
+
+	return name
+}
+
+// EndpointHost returns an endpoint's host. The hosts never get edge cases, since the back end validates them.
+func EndpointHost(r *rand.Rand) string {
+	return generator.EndpointHost(r)
+}
+
+// EndpointPath returns an endpoint's path, which might be an edge case.
+func EndpointPath(r *rand.Rand) string {
+	return maybeEdgeCase(r, generator.EndpointPath(r))
+}
+
+// EndpointPort returns an endpoint's port.
+func EndpointPort(r *rand.Rand) int {
+	return generator.EndpointPort(r)
+}
+
+// EndpointRole returns an endpoint's role.
+func EndpointRole(r *rand.Rand) string {
+	return generator.EndpointRole(r)
+}
+
+// EndpointScheme returns an endpoint's scheme.
+func EndpointScheme(r *rand.Rand) string {
+	return generator.EndpointScheme(r)
+}
+
+// AuthenticationName returns an authentication's name, which might be an edge case.
+func AuthenticationName(r *rand.Rand) string {
+	return maybeEdgeCase(r, generator.AuthenticationName(r))
+}
+
+// Username returns an authentication's username, which might be an edge case.
+func Username(r *rand.Rand) string {
+	return maybeEdgeCase(r, generator.Username(r))
+}
+
+// Password returns an authentication's password, which might be an edge case.
+func Password(r *rand.Rand) string {
+	return maybeEdgeCase(r, generator.Password(r))
+}
+
+// maybeEdgeCase returns an edge case instead of the given value as often as the configured edge case rate says.
+func maybeEdgeCase(r *rand.Rand, value string) string {
+	if isEdgeCase(r) {
+		return getEdgeCase(r)
+	}
+
+	return value
+}
+
+// isEdgeCase returns true when the next value should be an edge case. The random generator is always drawn from, so
+// that turning the edge cases on or off doesn't change the rest of the generated values.
+func isEdgeCase(r *rand.Rand) bool {
+	return r.Float64() < config.EdgeCaseRate
+}
+
+// getEdgeCase returns a random edge case.
+func getEdgeCase(r *rand.Rand) string {
+	switch r.Intn(3) {
+	case 0:
+		return unicodeEdgeCases[r.Intn(len(unicodeEdgeCases))]
+	case 1:
+		return specialCharacterEdgeCases[r.Intn(len(specialCharacterEdgeCases))]
+	default:
+		return getMaxLengthEdgeCase(r)
+	}
+}
+
+// getMaxLengthEdgeCase returns a string as long as the back end's string columns allow, made of either single byte or
+// multi byte characters.
+func getMaxLengthEdgeCase(r *rand.Rand) string {
+	alphabet := []rune("abcdefghijklmnopqrstuvwxyz")
+	if r.Intn(2) == 0 {
+		alphabet = []rune("ñçßøåéü日本語中文한국어")
+	}
+
+	var builder strings.Builder
+	for i := 0; i < maxLength; i++ {
+		builder.WriteRune(alphabet[r.Intn(len(alphabet))])
+	}
+
+	return builder.String()
+}
+
+// withSuffix appends the suffix to the value, trimming the value if needed so that the result doesn't exceed the
+// maximum length.
+func withSuffix(value string, suffix string) string {
+	maxValueLength := maxLength - utf8.RuneCountInString(suffix)
+	if utf8.RuneCountInString(value) > maxValueLength {
+		value = string([]rune(value)[:maxValueLength])
+	}
+
+	return value + suffix
+}
+
+// unicodeEdgeCases holds values with non ASCII characters, in different scripts and normalization forms.
+var unicodeEdgeCases = []string{
+	"Fuente de producción ñandú",
+	"Zażółć gęślą jaźń",
+	"生产环境数据源",
+	"本番環境のソース",
+	"프로덕션 소스",
+	"Πηγή παραγωγής",
+	"Источник данных",
+	"مصدر البيانات",
+	"מקור נתונים",
+	"🚀 Rocket source 🔥",
+	"👩‍💻 Team source 🏳️‍🌈",
+	"Cafe\u0301 with a combining accent",
+	"Zero\u200bwidth\u200bspaces",
+}
+
+// specialCharacterEdgeCases holds values with characters that tend to break quoting, escaping, searching or sorting.
+var specialCharacterEdgeCases = []string{
+	`O'Reilly's "quoted" source`,
+	`<script>alert("xss")</script>`,
+	`'; DROP TABLE sources; --`,
+	`back\slash\\double`,
+	`100% _wildcard_ match*`,
+	"tab\tand\nnew line",
+	"  leading and trailing spaces  ",
+	`{"json": ["in", "a", "string"]}`,
+	`../../etc/passwd`,
+	`?query=string&and=more#fragment`,
+	`$(whoami) ${HOME} ` + "`id`",
+	`~!@#$%^&*()_+-=[]{}|;:,.<>/?`,
+}
@@ -0,0 +1,135 @@
+package fakedata
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// realisticGenerator generates values which look like the ones real users enter, picked from word lists.
+type realisticGenerator struct{}
+
+// SourceName returns a name such as "Production cluster", followed by a UUID. There are just a few hundred prefix and
+// noun pairs, so the UUID is what keeps the names unique within a tenant, which the back end requires.
+func (realisticGenerator) SourceName(r *rand.Rand) string {
+	return fmt.Sprintf("%s %s %s", pick(r, sourceNamePrefixes), pick(r, sourceNameNouns), newUUID(r))
+}
+
+// EndpointHost returns a host name made of a service, an environment and a domain, such as "api.prod.example.com".
+func (realisticGenerator) EndpointHost(r *rand.Rand) string {
+	return fmt.Sprintf("%s.%s.%s", pick(r, hostServices), pick(r, hostEnvironments), pick(r, hostDomains))
+}
+
+// EndpointPath returns a path of one to three segments, such as "/api/v1/metrics".
+func (realisticGenerator) EndpointPath(r *rand.Rand) string {
+	segments := make([]string, 1+r.Intn(3))
+	for i := range segments {
+		segments[i] = pick(r, pathSegments)
+	}
+
+	return "/" + strings.Join(segments, "/")
+}
+
+// EndpointPort returns one of the ports the services usually listen on.
+func (realisticGenerator) EndpointPort(r *rand.Rand) int {
+	return ports[r.Intn(len(ports))]
+}
+
+// EndpointRole returns one of the roles the endpoints usually have.
+func (realisticGenerator) EndpointRole(r *rand.Rand) string {
+	return pick(r, roles)
+}
+
+// EndpointScheme returns "https" most of the time, and "http" otherwise.
+func (realisticGenerator) EndpointScheme(r *rand.Rand) string {
+	// Most of the endpoints are served over TLS.
+	if r.Intn(10) == 0 {
+		return "http"
+	}
+
+	return "https"
+}
+
+// AuthenticationName returns a name such as "Read-only credentials".
+func (realisticGenerator) AuthenticationName(r *rand.Rand) string {
+	return fmt.Sprintf("%s %s", pick(r, authenticationNamePrefixes), pick(r, authenticationNameNouns))
+}
+
+// Username returns a username in one of the usual formats: "first.last", an initial with the last name and a number, a
+// service account, or an email address.
+func (realisticGenerator) Username(r *rand.Rand) string {
+	firstName := strings.ToLower(pick(r, firstNames))
+	lastName := strings.ToLower(pick(r, lastNames))
+
+	switch r.Intn(4) {
+	case 0:
+		return fmt.Sprintf("%s.%s", firstName, lastName)
+	case 1:
+		return fmt.Sprintf("%c%s%d", firstName[0], lastName, r.Intn(100))
+	case 2:
+		return fmt.Sprintf("svc-%s-%s", pick(r, pathSegments), pick(r, hostEnvironments))
+	default:
+		return fmt.Sprintf("%s_%s@%s", firstName, lastName, pick(r, hostDomains))
+	}
+}
+
+// Password returns a password of 12 to 32 letters, digits and symbols.
+func (realisticGenerator) Password(r *rand.Rand) string {
+	const characters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*-_=+"
+
+	password := make([]byte, 12+r.Intn(21))
+	for i := range password {
+		password[i] = characters[r.Intn(len(characters))]
+	}
+
+	return string(password)
+}
+
+// pick returns a random element from the given list.
+func pick(r *rand.Rand, list []string) string {
+	return list[r.Intn(len(list))]
+}
+
+var sourceNamePrefixes = []string{
+	"Production", "Staging", "Development", "QA", "Sandbox", "Disaster recovery", "Analytics", "Finance", "Marketing",
+	"Research", "Legacy", "Shared services", "EMEA", "APAC", "North America", "Platform team", "Data science",
+}
+
+var sourceNameNouns = []string{
+	"cloud account", "cluster", "subscription", "billing account", "data lake", "workloads", "inventory", "project",
+	"landing zone", "tenant", "OpenShift cluster", "organization", "payer account", "lab",
+}
+
+var hostServices = []string{
+	"api", "console", "cluster", "metrics", "prometheus", "gateway", "ocp", "k8s", "satellite", "monitoring", "billing",
+}
+
+var hostEnvironments = []string{
+	"prod", "stage", "dev", "qa", "eu-west-1", "us-east-1", "us-west-2", "ap-southeast-2", "internal", "corp",
+}
+
+var hostDomains = []string{
+	"example.com", "acme.io", "contoso.net", "initech.org", "globex.dev", "umbrella.co", "hooli.xyz", "stark.cloud",
+}
+
+var pathSegments = []string{
+	"api", "v1", "v2", "metrics", "billing", "inventory", "reports", "status", "costs", "usage", "export", "cluster",
+}
+
+var ports = []int{80, 443, 6443, 8080, 8443, 9090, 9091}
+
+var roles = []string{"default", "kubernetes", "prometheus", "ansible", "satellite", "metrics"}
+
+var authenticationNamePrefixes = []string{
+	"Read-only", "Billing", "Cost management", "Service account", "Automation", "Monitoring", "Inventory", "Admin",
+}
+
+var authenticationNameNouns = []string{"credentials", "role", "token", "key", "access", "service principal"}
+
+var firstNames = []string{
+	"Jane", "John", "Maria", "Ahmed", "Wei", "Olga", "Kenji", "Amara", "Lucas", "Priya", "Noah", "Fatima", "Mateo",
+}
+
+var lastNames = []string{
+	"Doe", "Smith", "Garcia", "Hassan", "Zhang", "Ivanova", "Tanaka", "Okafor", "Silva", "Patel", "Miller", "Novak",
+}
@@ -0,0 +1,62 @@
+package fakedata
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/google/uuid"
+)
+
+// uuidGenerator generates the values from random UUIDs, which is how the program used to generate them. The values
+// are guaranteed to be unique, but they don't look like real data.
+type uuidGenerator struct{}
+
+// SourceName returns a name such as "<uuid>-name".
+func (uuidGenerator) SourceName(r *rand.Rand) string {
+	return fmt.Sprintf("%s-name", newUUID(r))
+}
+
+// EndpointHost returns a host such as "source-<uuid>.com".
+func (uuidGenerator) EndpointHost(r *rand.Rand) string {
+	return fmt.Sprintf("source-%s.com", newUUID(r))
+}
+
+// EndpointPath returns a path such as "/source-<uuid>".
+func (uuidGenerator) EndpointPath(r *rand.Rand) string {
+	return fmt.Sprintf("/source-%s", newUUID(r))
+}
+
+// EndpointPort always returns 443.
+func (uuidGenerator) EndpointPort(r *rand.Rand) int {
+	return 443
+}
+
+// EndpointRole returns a UUID.
+func (uuidGenerator) EndpointRole(r *rand.Rand) string {
+	return newUUID(r)
+}
+
+// EndpointScheme always returns "https".
+func (uuidGenerator) EndpointScheme(r *rand.Rand) string {
+	return "https"
+}
+
+// AuthenticationName returns a name such as "<uuid>-name".
+func (uuidGenerator) AuthenticationName(r *rand.Rand) string {
+	return fmt.Sprintf("%s-name", newUUID(r))
+}
+
+// Username returns a username such as "<uuid>-username".
+func (uuidGenerator) Username(r *rand.Rand) string {
+	return fmt.Sprintf("%s-username", newUUID(r))
+}
+
+// Password returns a password such as "<uuid>-password".
+func (uuidGenerator) Password(r *rand.Rand) string {
+	return fmt.Sprintf("%s-password", newUUID(r))
+}
+
+// newUUID returns a random UUID generated from the given random generator. Reading from a "rand.Rand" never fails.
+func newUUID(r *rand.Rand) string {
+	return uuid.Must(uuid.NewRandomFromReader(r)).String()
+}
@@ -12,11 +12,19 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/MikelAlejoBR/sources-database-populator/checkpoint"
 	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/fakedata"
 	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"github.com/MikelAlejoBR/sources-database-populator/manifest"
+	"github.com/MikelAlejoBR/sources-database-populator/metrics"
+	"github.com/MikelAlejoBR/sources-database-populator/plan"
+	"github.com/MikelAlejoBR/sources-database-populator/random"
+	"github.com/MikelAlejoBR/sources-database-populator/ratelimit"
+	"github.com/MikelAlejoBR/sources-database-populator/request"
 	"github.com/MikelAlejoBR/sources-database-populator/source_types_db"
+	"github.com/MikelAlejoBR/sources-database-populator/stats"
 	"github.com/RedHatInsights/sources-api-go/model"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -49,9 +57,16 @@ var (
 	createdSourcesTotal         uint64
 )
 
+// createdSourcesByType holds the number of created sources by source type id, and its mutex protects it.
+var (
+	createdSourcesByType      = make(map[string]uint64)
+	createdSourcesByTypeMutex sync.Mutex
+)
+
 // sourceTypesDb is the access to the in-memory database we will be using to store the different source types,
-// application types and their compatible authorization types.
-var sourceTypesDb = source_types_db.SourceTypesDb{}
+// application types and their compatible authorization types. It gets built by "initializeSourceTypesDb", since it
+// needs the configuration.
+var sourceTypesDb *source_types_db.SourceTypesDb
 
 // IdStruct is a helper struct to extract IDs from creation requests.
 type IdStruct struct {
@@ -65,17 +80,77 @@ func main() {
 	// Initialize the zap logger.
 	logger.InitializeLogger()
 
-	// Call the health check endpoint to confirm that the back end is up and running.
-	performHealthCheck()
+	// Build the HTTP client every request to the back end goes through.
+	request.InitializeClient()
+
+	// Start exposing the metrics, if the user asked for them.
+	metrics.InitializeMetrics()
+
+	// Call the health check endpoint to confirm that the back end is up and running. In a dry run the back end is not
+	// contacted at all, and neither it is when describing the types from a local source types file.
+	if !config.DryRun && !(config.Mode == config.ModeDescribeTypes && config.SourceTypesFile != "") {
+		performHealthCheck()
+	}
+
+	switch config.Mode {
+	case config.ModeCleanup:
+		cleanup()
+	case config.ModeDescribeTypes:
+		describeTypes()
+	case config.ModeVerify:
+		verify()
+	default:
+		populate()
+	}
+
+	// Make sure we flush the buffer from any logs.
+	logger.FlushLoggingBuffer()
+}
 
+// populate creates the sources and their sub resources for every tenant, and prints the statistics of the run at the
+// end.
+func populate() {
 	// Initialize the in memory database.
-	sourceTypesDb.InitializeDatabase()
+	initializeSourceTypesDb()
+	exitOnStrictCatalogueIssues()
+
+	// Pick the generator for the resources' names, hosts, usernames and the rest of the field values.
+	fakedata.InitializeGenerator()
+
+	// Set up the checkpoint before initializing the tenants, since when resuming a previous run the tenants come from
+	// the checkpoint file.
+	checkpoint.InitializeCheckpoint(sampleTargetSources)
+
+	// The sources of a resumed run get their source types from the source type counts by their index, so the counts
+	// must add up to the number of sources the checkpoint was created with.
+	if len(config.SourceTypeCounts) > 0 {
+		for _, tenant := range config.Tenants {
+			if targetSources := checkpoint.GetTargetSources(tenant, sampleTargetSources); targetSources != sourceTypesDb.GetCountedSourcesPerTenant() {
+				logger.Logger.Fatalw(
+					"the source type counts don't add up to the number of sources of the checkpoint to resume",
+					zap.String("tenant_id", tenant),
+					zap.Int("checkpoint_sources", targetSources),
+					zap.Int("counted_sources", sourceTypesDb.GetCountedSourcesPerTenant()),
+				)
+			}
+		}
+	}
 
 	// Before starting, we "initialize" all the tenants. This means that we send some dummy requests to "/sources" so
 	// that the tenants get picked up, and they get created on the database. This avoids hitting the "duplicated
 	// constraint" on the tenants table, which fires up when we send two simultaneous requests which contain a tenant
 	// that has yet to be created in the database.
-	initializeTenants()
+	if config.DryRun {
+		plan.InitializePlan()
+	} else {
+		initializeTenants()
+	}
+
+	// Create the manifest file where all the created resources will be recorded.
+	manifest.InitializeManifest()
+
+	// Start the rate limiters right before sending the creation requests, so that the ramp up starts with them.
+	ratelimit.InitializeRateLimiters()
 
 	// Get the time before starting the process so that we can calculate the elapsed time afterwards.
 	startTs := time.Now()
@@ -83,30 +158,57 @@ func main() {
 	// Start the process.
 	for _, tenant := range config.Tenants {
 		var wg sync.WaitGroup
-		for i := 0; i < config.SourcesPerTenant; i++ {
+
+		// When resuming a previous run, first finish creating the sub resources of the sources which were left
+		// incomplete.
+		for _, source := range checkpoint.GetPendingSources(tenant) {
+			wg.Add(1)
+			go func(source checkpoint.Source) {
+				defer wg.Done()
+
+				createSubresources(tenant, source)
+			}(source)
+		}
+
+		// The sources are identified by their index in the tenant, which skips the indexes of the sources that were
+		// created in the run that is being resumed, if any.
+		targetSources := checkpoint.GetTargetSources(tenant, sampleTargetSources)
+
+		for _, index := range checkpoint.GetRemainingSourceIndexes(tenant, targetSources) {
 			wg.Add(1)
-			go func() {
+			go func(index int) {
 				defer wg.Done()
 
-				sourceId, sourceTypeId, ok := createSource(tenant)
+				sourceKey := getSourceKey(tenant, index)
+
+				sourceId, sourceTypeId, ok := createSource(tenant, sourceKey, index)
 				if !ok {
 					return
 				}
 
-				createApplications(tenant, sourceTypeId, sourceId)
-				createAuthenticationsSource(tenant, sourceTypeId, sourceId)
-				createEndpoints(tenant, sourceId)
-				createRhcConnections(tenant, sourceId)
+				recordCreatedSource(sourceTypeId)
+
+				targets := sampleSourceTargets(sourceKey)
+
+				checkpoint.SourceCreated(tenant, sourceId, index, sourceTypeId, targets)
+
+				createSubresources(tenant, checkpoint.Source{Id: sourceId, Index: index, SourceTypeId: sourceTypeId, Targets: targets})
 
 				atomic.AddUint64(&createdSourcesTotal, 1)
-			}()
+			}(index)
 		}
 
 		wg.Wait()
 	}
 
 	// Calculate the elapsed time.
-	elapsedTime := time.Since(startTs).String()
+	elapsed := time.Since(startTs)
+	elapsedTime := elapsed.String()
+
+	// All the resources have been created by now, so we can close the manifest and write the final checkpoint.
+	manifest.CloseManifest()
+	checkpoint.CloseCheckpoint()
+	plan.ClosePlan()
 
 	// Store the information in a map.
 	results := map[string]interface{}{
@@ -119,6 +221,53 @@ func main() {
 		"created_rhc_connections": createdRhcConnectionsTotal,
 	}
 
+	// Break the created sources down by their source type's name.
+	createdSourcesByTypeName := make(map[string]uint64)
+	for _, st := range sourceTypesDb.GetSourceTypes() {
+		if count, ok := createdSourcesByType[st.Id]; ok {
+			createdSourcesByTypeName[st.Name] = count
+		}
+	}
+	results["created_sources_by_type"] = createdSourcesByTypeName
+
+	// The latencies are only measured when the requests are actually sent.
+	if !config.DryRun {
+		latencies, requestsPerSecond := stats.GetSummaries(elapsed)
+
+		results["latencies"] = latencies
+		results["requests_per_second"] = requestsPerSecond
+		results["retries"] = stats.GetRetries()
+	}
+
+	// List the account number and the org ID of every tenant, in the same order as the tenants themselves, so that it
+	// is easy to tell which kind of tenants were populated.
+	tenantIdentifiers := make([]config.TenantIdentifiers, 0, len(config.Tenants))
+	for _, tenant := range config.Tenants {
+		identifiers, err := config.GetTenantIdentifiers(tenant)
+		if err != nil {
+			logger.Logger.Errorw("could not get the tenant's identifiers", zap.Error(err), zap.String("tenant", tenant))
+		}
+
+		tenantIdentifiers = append(tenantIdentifiers, identifiers)
+	}
+	results["tenant_identifiers"] = tenantIdentifiers
+
+	if config.ManifestFile != "" {
+		results["manifest_file"] = config.ManifestFile
+	}
+
+	if config.DryRun {
+		results["dry_run"] = true
+	}
+
+	if config.Seed != "" {
+		results["seed"] = config.Seed
+	}
+
+	if config.CheckpointFile != "" || config.ResumeFile != "" {
+		results["resumed"] = config.ResumeFile != ""
+	}
+
 	// We don't want to use the logger here, since the user could end up shadowing the message depending on the log
 	// level that they decide to use. And to be fair, the statistics should be an "info" message, but again, if the
 	// user decides to log only the "error" messages, they would not be able to see which tenants they have to query
@@ -141,15 +290,61 @@ func main() {
 			zap.Uint64("created_rhc_connections", createdRhcConnectionsTotal),
 		)
 	}
+}
 
-	// Make sure we flush the buffer from any logs.
-	logger.FlushLoggingBuffer()
+// initializeSourceTypesDb builds the in memory database with the configured filters, counts and weights, and fills it
+// with the configured catalogue: the local source types file if there is one, or the back end's otherwise.
+func initializeSourceTypesDb() {
+	sourceTypesDb = source_types_db.NewSourceTypesDb(source_types_db.Options{
+		IncludeSourceTypes:      config.IncludeSourceTypes,
+		IncludeApplicationTypes: config.IncludeApplicationTypes,
+		ExcludeSourceTypes:      config.ExcludeSourceTypes,
+		ExcludeApplicationTypes: config.ExcludeApplicationTypes,
+		SourceTypeCounts:        config.SourceTypeCounts,
+		SourceTypeWeights:       config.SourceTypeWeights,
+		ExportFile:              config.SourceTypesExportFile,
+	})
+
+	setHeaders := func(req *http.Request) {
+		request.SetHeaders(req, request.DefaultTenant)
+	}
+
+	sourceTypesDb.InitializeDatabase(source_types_db.NewLoader(
+		config.SourceTypesFile,
+		config.SourceTypesUrl,
+		config.ApplicationTypesUrl,
+		request.Client,
+		setHeaders,
+		config.CatalogueTimeout,
+	))
+}
+
+// exitOnStrictCatalogueIssues exits the program if the catalogue has integrity issues in the strict catalogue mode. The
+// issues caused by the user's own filters don't count.
+func exitOnStrictCatalogueIssues() {
+	if !config.StrictCatalogue {
+		return
+	}
+
+	var integrityIssues int
+	for _, issue := range sourceTypesDb.GetIssues() {
+		if issue.IsIntegrityIssue() {
+			integrityIssues++
+		}
+	}
+
+	if integrityIssues > 0 {
+		logger.Logger.Fatalw(
+			"the catalogue has integrity issues, and the strict catalogue mode is enabled",
+			zap.Int("issues", integrityIssues),
+		)
+	}
 }
 
 // performHealthCheck sends a request to the back end's "/health" endpoint to check that it is online.
 func performHealthCheck() {
 	// Before proceeding, send a request to the health check endpoint to be sure that the back end is running.
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.HealthCheckTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.SourcesApiHealthUrl, nil)
@@ -161,11 +356,9 @@ func performHealthCheck() {
 		)
 	}
 
-	req.Header.Set("Accept", "application/json")
-	// A "x-rh-identity" with an "account number: 12345"
-	req.Header.Set("x-rh-identity", "ewogICAgImlkZW50aXR5IjogewogICAgICAgICJhY2NvdW50X251bWJlciI6ICIxMjM0NSIKICAgIH0KfQ==")
+	request.SetHeaders(req, request.DefaultTenant)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := request.Client.Do(req)
 	if err != nil {
 		logger.Logger.Fatalw(
 			"could not send the health check request",
@@ -193,7 +386,7 @@ func performHealthCheck() {
 // end's database. This ensures that we don't hit the "duplicate tenant" constraint when we send two requests which
 // have a tenant yet to be created in the database.
 func initializeTenants() {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), config.TenantInitializationTimeout)
 	defer cancel()
 
 	var wg sync.WaitGroup
@@ -214,12 +407,11 @@ func initializeTenants() {
 				)
 			}
 
-			req.Header.Add("Accept", "application/json")
-			req.Header.Add("x-rh-identity", tenant)
+			request.SetHeaders(req, tenant)
 
 			logger.Logger.Debugw("Tenant initialization request to be sent", zap.Any("request", req))
 
-			res, err := http.DefaultClient.Do(req)
+			res, err := request.Client.Do(req)
 			if err != nil {
 				logger.Logger.Fatalw(
 					"could not send the tenant initialization request",
@@ -265,44 +457,99 @@ func initializeTenants() {
 }
 
 // getRandomAppCreationWorkflow returns a random app creation workflow.
-func getRandomAppCreationWorkflow() string {
-	idx := rand.Intn(1)
+func getRandomAppCreationWorkflow(r *rand.Rand) string {
+	idx := r.Intn(1)
 
 	return appCreationWorkflows[idx]
 }
 
 // getRandomAvailabilityStatus returns a random availability status.
-func getRandomAvailabilityStatus() string {
-	idx := rand.Intn(3)
+func getRandomAvailabilityStatus(r *rand.Rand) string {
+	idx := r.Intn(3)
 
 	return availabilityStatuses[idx]
 }
 
 // getRandomEndpointAvailabilityStatus returns a random availability status for endpoints.
-func getRandomEndpointAvailabilityStatus() string {
-	idx := rand.Intn(2)
+func getRandomEndpointAvailabilityStatus(r *rand.Rand) string {
+	idx := r.Intn(2)
 
 	return endpointAvailabilityStatuses[idx]
 }
 
-// createSource takes a target tenant and creates random sources and sub resources. That is: authentications, endpoints,
-// applications and rhc connections.
-func createSource(tenant string) (string, string, bool) {
-	st := sourceTypesDb.GetRandomSourceType()
+// getSourceKey returns the key which the random data of the tenant's source with the given index is generated from.
+func getSourceKey(tenant string, index int) string {
+	return fmt.Sprintf("%s/source/%d", tenant, index)
+}
 
-	uid, err := uuid.NewUUID()
+// recordCreatedSource adds a created source of the given source type to the per source type breakdown.
+func recordCreatedSource(sourceTypeId string) {
+	createdSourcesByTypeMutex.Lock()
+	defer createdSourcesByTypeMutex.Unlock()
+
+	createdSourcesByType[sourceTypeId]++
+}
+
+// sampleTargetSources samples the number of sources to create for the given tenant.
+func sampleTargetSources(tenant string) int {
+	if len(config.SourceTypeCounts) > 0 {
+		return sourceTypesDb.GetCountedSourcesPerTenant()
+	}
+
+	return config.SourcesPerTenant.Sample(random.New(tenant + "/targets"))
+}
+
+// sampleSourceTargets samples the number of sub resources to create for the source with the given key.
+func sampleSourceTargets(sourceKey string) checkpoint.SourceTargets {
+	r := random.New(sourceKey + "/targets")
+
+	return checkpoint.SourceTargets{
+		Authentications: config.AuthenticationsPerResource.Sample(r),
+		Endpoints:       config.EndpointsPerSource.Sample(r),
+		RhcConnections:  config.RhcConnectionsPerTenant.Sample(r),
+		Applications:    config.ApplicationsPerSource.Sample(r),
+	}
+}
+
+// sampleApplicationAuthentications samples the number of authentications to create for the source's application of the
+// given type.
+func sampleApplicationAuthentications(sourceKey string, applicationTypeId string) int {
+	r := random.New(fmt.Sprintf("%s/application/%s/targets", sourceKey, applicationTypeId))
+
+	return config.AuthenticationsPerResource.Sample(r)
+}
+
+// createSource takes a target tenant and creates a random source, whose random data is generated from the given source
+// key. The source's index in the tenant determines its source type when the user specified the exact number of sources
+// per source type. It returns the ID of the created source and its source type ID.
+func createSource(tenant string, sourceKey string, index int) (string, string, bool) {
+	r := random.New(sourceKey)
+
+	var st source_types_db.SourceType
+	if len(config.SourceTypeCounts) > 0 {
+		var err error
+		st, err = sourceTypesDb.GetSourceTypeByIndex(index)
+		if err != nil {
+			logger.Logger.Errorw(`could not get the source type of the source. Skipping...`, zap.Error(err), zap.String("tenant_id", tenant))
+			return "", "", false
+		}
+	} else {
+		st = sourceTypesDb.GetRandomSourceType(r)
+	}
+
+	uid, err := random.NewUUID(r)
 	if err != nil {
 		logger.Logger.Errorw(`could not generate UUID when generating a source. Skipping...`, zap.Error(err))
 		return "", "", false
 	}
 
-	name := fmt.Sprintf("%s-name", uid)
+	name := fakedata.SourceName(r)
 	uidStr := uid.String()
 	source := model.SourceCreateRequest{
 		Name:                &name,
 		Uid:                 &uidStr,
-		AppCreationWorkflow: getRandomAppCreationWorkflow(),
-		AvailabilityStatus:  getRandomAvailabilityStatus(),
+		AppCreationWorkflow: getRandomAppCreationWorkflow(r),
+		AvailabilityStatus:  getRandomAvailabilityStatus(r),
 		SourceTypeIDRaw:     st.Id,
 	}
 
@@ -316,7 +563,7 @@ func createSource(tenant string) (string, string, bool) {
 		return "", "", false
 	}
 
-	resBody, isSuccess := sendCreationRequest("source", tenant, config.SourceCreateUrl, body)
+	resBody, latency, isSuccess := sendCreationRequest("source", tenant, config.SourceCreateUrl, body)
 	if !isSuccess {
 		return "", "", false
 	}
@@ -344,19 +591,48 @@ func createSource(tenant string) (string, string, bool) {
 		zap.String("id", sourceId.Id),
 	)
 
+	manifest.Record(manifest.Entry{
+		Tenant:         tenant,
+		ResourceType:   "source",
+		Id:             sourceId.Id,
+		SourceTypeId:   st.Id,
+		SourceTypeName: st.Name,
+		LatencyMs:      latency.Seconds() * 1000,
+	})
+
 	return sourceId.Id, st.Id, true
 }
 
-// createRhcConnections creates rhc connections related to the given source.
-func createRhcConnections(tenant string, sourceId string) {
+// createSubresources creates the sub resources of the given source that haven't been created yet, and it marks the
+// source as completed in the checkpoint when all of them have been successfully created.
+func createSubresources(tenant string, source checkpoint.Source) {
+	sourceKey := getSourceKey(tenant, source.Index)
+
+	isComplete := createApplications(tenant, sourceKey, source)
+	isComplete = createAuthenticationsSource(tenant, sourceKey, source) && isComplete
+	isComplete = createEndpoints(tenant, sourceKey, source) && isComplete
+	isComplete = createRhcConnections(tenant, sourceKey, source) && isComplete
+
+	if isComplete {
+		checkpoint.SourceCompleted(tenant, source.Id)
+	}
+}
+
+// createRhcConnections creates the rhc connections that the given source is missing. It returns true if all of them
+// were successfully created.
+func createRhcConnections(tenant string, sourceKey string, source checkpoint.Source) bool {
+	sourceId := source.Id
+	indexes := checkpoint.GetMissingIndexes(source.RhcConnectionIndexes, source.Targets.RhcConnections-source.RhcConnections)
+
+	var created uint64
 	var wg sync.WaitGroup
 
-	for i := 0; i < config.RhcConnectionsPerTenant; i++ {
+	for _, index := range indexes {
 		wg.Add(1)
-		go func() {
+		go func(index int) {
 			defer wg.Done()
 
-			uid, err := uuid.NewUUID()
+			uid, err := random.NewUUID(random.New(fmt.Sprintf("%s/rhcConnection/%d", sourceKey, index)))
 			if err != nil {
 				logger.Logger.Errorw("could not generate UUID when generating a rhc connection. Skipping...", zap.Error(err))
 				return
@@ -377,7 +653,7 @@ func createRhcConnections(tenant string, sourceId string) {
 				return
 			}
 
-			resBody, isSuccess := sendCreationRequest("rhcConnection", tenant, config.RhcConnectionCreateUrl, body)
+			resBody, latency, isSuccess := sendCreationRequest("rhcConnection", tenant, config.RhcConnectionCreateUrl, body)
 			if !isSuccess {
 				return
 			}
@@ -405,32 +681,51 @@ func createRhcConnections(tenant string, sourceId string) {
 				zap.String("id", rhcConnectionId.Id),
 			)
 
+			manifest.Record(manifest.Entry{
+				Tenant:       tenant,
+				ResourceType: "rhcConnection",
+				Id:           rhcConnectionId.Id,
+				ParentType:   "source",
+				ParentId:     sourceId,
+				LatencyMs:    latency.Seconds() * 1000,
+			})
+
+			checkpoint.RhcConnectionCreated(tenant, sourceId, index)
+
+			atomic.AddUint64(&created, 1)
 			atomic.AddUint64(&createdRhcConnectionsTotal, 1)
-		}()
+		}(index)
 	}
 
 	wg.Wait()
+
+	return int(created) >= len(indexes)
 }
 
-// createEndpoints creates the endpoints related to the given source.
-func createEndpoints(tenant string, sourceId string) {
+// createEndpoints creates the endpoints that the given source is missing. It returns true if all of them were
+// successfully created.
+func createEndpoints(tenant string, sourceKey string, source checkpoint.Source) bool {
+	sourceId := source.Id
+	indexes := checkpoint.GetMissingIndexes(source.EndpointIndexes, source.Targets.Endpoints-source.Endpoints)
+
+	var created uint64
 	var wg sync.WaitGroup
-	for i := 0; i < config.EndpointsPerSource; i++ {
+	for _, index := range indexes {
 		wg.Add(1)
-		go func() {
+		go func(index int) {
 			defer wg.Done()
 
-			uid, err := uuid.NewUUID()
-			if err != nil {
-				logger.Logger.Errorw(`could not generate UUID when generating an endpoint. Skipping...`, zap.Error(err))
-				return
-			}
+			r := random.New(fmt.Sprintf("%s/endpoint/%d", sourceKey, index))
 
+			scheme := fakedata.EndpointScheme(r)
+			port := fakedata.EndpointPort(r)
 			endpoint := model.EndpointCreateRequest{
-				AvailabilityStatus: getRandomEndpointAvailabilityStatus(),
-				Host:               fmt.Sprintf("source-%s.com", sourceId),
-				Path:               fmt.Sprintf("/source-%s", sourceId),
-				Role:               uid.String(),
+				AvailabilityStatus: getRandomEndpointAvailabilityStatus(r),
+				Scheme:             &scheme,
+				Host:               fakedata.EndpointHost(r),
+				Port:               &port,
+				Path:               fakedata.EndpointPath(r),
+				Role:               fakedata.EndpointRole(r),
 				SourceIDRaw:        sourceId,
 			}
 
@@ -444,7 +739,7 @@ func createEndpoints(tenant string, sourceId string) {
 				return
 			}
 
-			resBody, isSuccess := sendCreationRequest("endpoint", tenant, config.EndpointCreateUrl, body)
+			resBody, latency, isSuccess := sendCreationRequest("endpoint", tenant, config.EndpointCreateUrl, body)
 			if !isSuccess {
 				return
 			}
@@ -473,71 +768,107 @@ func createEndpoints(tenant string, sourceId string) {
 				zap.String("id", endpointId.Id),
 			)
 
+			manifest.Record(manifest.Entry{
+				Tenant:       tenant,
+				ResourceType: "endpoint",
+				Id:           endpointId.Id,
+				ParentType:   "source",
+				ParentId:     sourceId,
+				LatencyMs:    latency.Seconds() * 1000,
+			})
+
+			checkpoint.EndpointCreated(tenant, sourceId, index)
+
+			atomic.AddUint64(&created, 1)
 			atomic.AddUint64(&createdEndpointsTotal, 1)
-		}()
+		}(index)
 	}
 
 	wg.Wait()
+
+	return int(created) >= len(indexes)
 }
 
-// createAuthenticationsSource creates authentications for the given source. It makes sure to create compatible
-// authentications for that source.
-func createAuthenticationsSource(tenant string, sourceTypeId string, sourceId string) {
+// createAuthenticationsSource creates the authentications that the given source is missing. It makes sure to create
+// compatible authentications for that source, and it returns true if all of them were successfully created.
+func createAuthenticationsSource(tenant string, sourceKey string, source checkpoint.Source) bool {
+	// The source types without authentication types, which are reported when the catalogue is loaded, cannot have
+	// any authentications, so their sources are complete without them.
+	if !sourceTypesDb.HasAuthenticationTypes(source.SourceTypeId) {
+		return true
+	}
+
+	indexes := checkpoint.GetMissingIndexes(source.AuthenticationIndexes, source.Targets.Authentications-source.Authentications)
+
+	var created uint64
 	var wg sync.WaitGroup
-	for i := 0; i < config.AuthenticationsPerResource; i++ {
+	for _, index := range indexes {
 		wg.Add(1)
-		go func() {
+		go func(index int) {
 			defer wg.Done()
 
-			authType := sourceTypesDb.GetRandomAuthenticationTypeForSource(sourceTypeId)
+			r := random.New(fmt.Sprintf("%s/authentication/%d", sourceKey, index))
+
+			authType := sourceTypesDb.GetRandomAuthenticationTypeForSource(r, source.SourceTypeId)
 
-			isSuccess := createAuthentications(tenant, authType, "Source", sourceId)
+			isSuccess := createAuthentications(tenant, r, authType, "Source", source.Id)
 			if !isSuccess {
 				return
 			}
 
+			checkpoint.SourceAuthenticationCreated(tenant, source.Id, index)
+
+			atomic.AddUint64(&created, 1)
 			atomic.AddUint64(&createdAuthenticationsTotal, 1)
-		}()
+		}(index)
 	}
 
 	wg.Wait()
+
+	return int(created) >= len(indexes)
 }
 
-// createAuthenticationsApplication creates authentications for the given application. It makes sure to create
-// authentications that are compatible with the application in the given source.
-func createAuthenticationsApplication(tenant string, sourceTypeId string, applicationTypeId, applicationId string) {
+// createAuthenticationsApplication creates the authentications that the given application is missing. It makes sure to
+// create authentications that are compatible with the application in the given source, and it returns true if all of
+// them were successfully created.
+func createAuthenticationsApplication(tenant string, sourceKey string, source checkpoint.Source, applicationTypeId string, application checkpoint.Application) bool {
+	applicationId := application.Id
+	indexes := checkpoint.GetMissingIndexes(application.AuthenticationIndexes, application.TargetAuthentications-application.Authentications)
+
+	var created uint64
 	var wg sync.WaitGroup
-	for i := 0; i < config.AuthenticationsPerResource; i++ {
+	for _, index := range indexes {
 		wg.Add(1)
-		go func() {
+		go func(index int) {
 			defer wg.Done()
 
-			authType := sourceTypesDb.GetRandomAuthenticationTypeForApplication(sourceTypeId, applicationTypeId)
+			r := random.New(fmt.Sprintf("%s/application/%s/authentication/%d", sourceKey, applicationTypeId, index))
+
+			authType := sourceTypesDb.GetRandomAuthenticationTypeForApplication(r, source.SourceTypeId, applicationTypeId)
 
-			isSuccess := createAuthentications(tenant, authType, "Application", applicationId)
+			isSuccess := createAuthentications(tenant, r, authType, "Application", applicationId)
 			if !isSuccess {
 				return
 			}
 
+			checkpoint.ApplicationAuthenticationCreated(tenant, source.Id, applicationTypeId, index)
+
+			atomic.AddUint64(&created, 1)
 			atomic.AddUint64(&createdAuthenticationsTotal, 1)
-		}()
+		}(index)
 	}
 
 	wg.Wait()
+
+	return int(created) >= len(indexes)
 }
 
 // createAuthentications is a generic function which creates authentications for the specified resource type and
-// resource id.
-func createAuthentications(tenant string, authType string, resourceType string, resourceId string) bool {
-	uid, err := uuid.NewUUID()
-	if err != nil {
-		logger.Logger.Errorw("could not generate UUID when generating an authentication. Skipping...", zap.Error(err))
-		return false
-	}
-
-	name := fmt.Sprintf("%s-name", uid)
-	username := fmt.Sprintf("%s-username", uid)
-	password := fmt.Sprintf("%s-password", uid)
+// resource id, generating their random data with the given random generator.
+func createAuthentications(tenant string, r *rand.Rand, authType string, resourceType string, resourceId string) bool {
+	name := fakedata.AuthenticationName(r)
+	username := fakedata.Username(r)
+	password := fakedata.Password(r)
 	authentication := model.AuthenticationCreateRequest{
 		AuthType:      authType,
 		Name:          &name,
@@ -557,7 +888,7 @@ func createAuthentications(tenant string, authType string, resourceType string,
 		return false
 	}
 
-	resBody, isSuccess := sendCreationRequest("authentication", tenant, config.AuthenticationCreateUrl, body)
+	resBody, latency, isSuccess := sendCreationRequest("authentication", tenant, config.AuthenticationCreateUrl, body)
 	if !isSuccess {
 		return false
 	}
@@ -585,17 +916,79 @@ func createAuthentications(tenant string, authType string, resourceType string,
 		zap.String("authentication_id", authenticationId.Id),
 	)
 
+	manifest.Record(manifest.Entry{
+		Tenant:       tenant,
+		ResourceType: "authentication",
+		Id:           authenticationId.Id,
+		ParentType:   resourceType,
+		ParentId:     resourceId,
+		AuthType:     authType,
+		LatencyMs:    latency.Seconds() * 1000,
+	})
+
 	return true
 }
 
-// createApplications creates the application and its authentications which are compatible with the provided source.
-func createApplications(tenant string, sourceTypeId string, sourceId string) {
+// createApplications creates the source's applications and their authentications, picking a random subset of the
+// source type's compatible application types as big as the source's target number of applications. The applications
+// that the source already has only get their missing authentications created. It returns true if all the applications
+// and authentications were successfully created.
+func createApplications(tenant string, sourceKey string, source checkpoint.Source) bool {
+	// The applications' authentications are created concurrently, and we need to wait for them before returning so
+	// that they are accounted for in the statistics, the manifest and the checkpoint.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	// isComplete is set to zero by the authentications' goroutines if any of them fail.
+	var isComplete uint32 = 1
+	createAppAuthentications := func(appTypeId string, application checkpoint.Application) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if !createAuthenticationsApplication(tenant, sourceKey, source, appTypeId, application) {
+				atomic.StoreUint32(&isComplete, 0)
+			}
+		}()
+	}
+
+	// Shuffle the compatible application types, so that the first ones are the random subset to create.
+	appTypes := sourceTypesDb.GetApplicationTypes(source.SourceTypeId)
+	r := random.New(sourceKey + "/applications")
+	r.Shuffle(len(appTypes), func(i, j int) {
+		appTypes[i], appTypes[j] = appTypes[j], appTypes[i]
+	})
+
+	targetApplications := source.Targets.Applications
+
+	// The applications might have been created in a previous run that we are resuming. They count towards the target,
+	// and they only get their missing authentications created.
+	var missingAppTypes []source_types_db.ApplicationType
+	for _, appType := range appTypes {
+		app, ok := source.Applications[appType.Id]
+		if !ok {
+			missingAppTypes = append(missingAppTypes, appType)
+			continue
+		}
+
+		targetApplications--
+		createAppAuthentications(appType.Id, *app)
+	}
+
+	if targetApplications < 0 {
+		targetApplications = 0
+	}
+	if targetApplications < len(missingAppTypes) {
+		missingAppTypes = missingAppTypes[:targetApplications]
+	}
+
 	// We don't run the application type creation code on multiple threads because there are just a few application
 	// types per source, and doing it synchronously is fast enough. Plus, we avoid
-	for _, appType := range sourceTypesDb.GetApplicationTypes(sourceTypeId) {
+	for _, appType := range missingAppTypes {
+
 		application := model.ApplicationCreateRequest{
 			ApplicationTypeIDRaw: appType.Id,
-			SourceIDRaw:          sourceId,
+			SourceIDRaw:          source.Id,
 		}
 
 		body, err := json.Marshal(application)
@@ -605,12 +998,12 @@ func createApplications(tenant string, sourceTypeId string, sourceId string) {
 				zap.Error(err),
 				zap.Any("application_create_request", application),
 			)
-			return
+			return false
 		}
 
-		resBody, isSuccess := sendCreationRequest("application", tenant, config.ApplicationCreateUrl, body)
+		resBody, latency, isSuccess := sendCreationRequest("application", tenant, config.ApplicationCreateUrl, body)
 		if !isSuccess {
-			return
+			return false
 		}
 
 		var applicationId IdStruct
@@ -621,13 +1014,13 @@ func createApplications(tenant string, sourceTypeId string, sourceId string) {
 				zap.Error(err),
 				zap.Any("response_body", json.RawMessage(resBody)),
 			)
-			return
+			return false
 		}
 
 		logger.Logger.Debugw(
 			"Application creation's response body",
 			zap.String("tenant_id", tenant),
-			zap.String("source_id", sourceId),
+			zap.String("source_id", source.Id),
 			zap.Any("response_body", json.RawMessage(resBody)),
 		)
 		logger.Logger.Infow(
@@ -635,20 +1028,49 @@ func createApplications(tenant string, sourceTypeId string, sourceId string) {
 			zap.String("application_id", applicationId.Id),
 		)
 
+		manifest.Record(manifest.Entry{
+			Tenant:            tenant,
+			ResourceType:      "application",
+			Id:                applicationId.Id,
+			ParentType:        "source",
+			ParentId:          source.Id,
+			SourceTypeId:      source.SourceTypeId,
+			ApplicationTypeId: appType.Id,
+			LatencyMs:         latency.Seconds() * 1000,
+		})
+
+		targetAuthentications := sampleApplicationAuthentications(sourceKey, appType.Id)
+
+		checkpoint.ApplicationCreated(tenant, source.Id, appType.Id, applicationId.Id, targetAuthentications)
+
 		atomic.AddUint64(&createdApplicationsTotal, 1)
 
-		go createAuthenticationsApplication(tenant, sourceTypeId, appType.Id, applicationId.Id)
+		createAppAuthentications(appType.Id, checkpoint.Application{Id: applicationId.Id, TargetAuthentications: targetAuthentications})
 	}
+
+	wg.Wait()
+
+	return atomic.LoadUint32(&isComplete) == 1
 }
 
-// sendCreationRequest is a generic function which sends a resource creation request to the back end.
-func sendCreationRequest(resourceType string, tenant string, url string, body []byte) ([]byte, bool) {
-	// We use a channel as the throttler for the number of simultaneous requests. Each new process will write to the
-	// channel, "allocating a slot" to perform the request. Once the request is done, the process will read from the
-	// channel, "freeing the slot" so that other processes can perform their requests. If the channel is full of
-	// values, the process will block here until some other process frees a slot.
-	config.ConcurrentRequests <- struct{}{}
+// creationAttempt holds the outcome of a single attempt at sending a creation request.
+type creationAttempt struct {
+	// Body is the response's body.
+	Body []byte
+	// Latency is the time it took for the back end to respond.
+	Latency time.Duration
+	// IsSuccess is true when the resource got created.
+	IsSuccess bool
+	// IsRetryable is true when the attempt failed with a transient error, and therefore it is worth retrying.
+	IsRetryable bool
+	// RetryAfter is the delay the back end asked for in the "Retry-After" header, if any.
+	RetryAfter time.Duration
+}
 
+// sendCreationRequest is a generic function which sends a resource creation request to the back end, retrying it when
+// it fails with a transient error. Along with the response's body, it returns the time it took for the back end to
+// respond to the last attempt.
+func sendCreationRequest(resourceType string, tenant string, url string, body []byte) ([]byte, time.Duration, bool) {
 	logger.Logger.Debugw(
 		"Request parameters for the creation request",
 		zap.String("resource_type", resourceType),
@@ -657,7 +1079,52 @@ func sendCreationRequest(resourceType string, tenant string, url string, body []
 		zap.Any("body", json.RawMessage(body)),
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// In a dry run the request just gets recorded in the plan, which gives us back a fake response.
+	if config.DryRun {
+		config.ConcurrentRequests <- struct{}{}
+		defer func() { <-config.ConcurrentRequests }()
+
+		return plan.Record(resourceType, tenant, url, body), 0, true
+	}
+
+	for retry := 0; ; retry++ {
+		attempt := sendCreationAttempt(resourceType, tenant, url, body)
+		if attempt.IsSuccess || !attempt.IsRetryable || retry >= config.MaxRetries {
+			return attempt.Body, attempt.Latency, attempt.IsSuccess
+		}
+
+		delay := getRetryDelay(retry, attempt.RetryAfter)
+
+		logger.Logger.Infow(
+			"Retrying the creation request",
+			zap.Int("retry", retry+1),
+			zap.Int("max_retries", config.MaxRetries),
+			zap.String("delay", delay.String()),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+
+		stats.RecordRetry(resourceType)
+
+		// The slot in the throttler is not held while waiting, so that other requests can be sent in the meantime.
+		time.Sleep(delay)
+	}
+}
+
+// sendCreationAttempt sends a single resource creation request to the back end.
+func sendCreationAttempt(resourceType string, tenant string, url string, body []byte) creationAttempt {
+	// Wait for our turn when the requests are rate limited. Every attempt counts towards the rate limit, since the back
+	// end receives them all.
+	ratelimit.Wait(resourceType)
+
+	// We use a channel as the throttler for the number of simultaneous requests. Each new process will write to the
+	// channel, "allocating a slot" to perform the request. Once the request is done, the process will read from the
+	// channel, "freeing the slot" so that other processes can perform their requests. If the channel is full of
+	// values, the process will block here until some other process frees a slot.
+	config.ConcurrentRequests <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetTimeout(resourceType))
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
@@ -672,18 +1139,22 @@ func sendCreationRequest(resourceType string, tenant string, url string, body []
 		)
 
 		<-config.ConcurrentRequests
-		return nil, false
+		return creationAttempt{}
 	}
 
-	req.Header.Add("Accept", "application/json")
-	req.Header.Add("x-rh-identity", tenant)
+	request.SetHeaders(req, tenant)
 
 	logger.Logger.Debugw("Request to be sent", zap.Any("request", req))
 
-	res, err := http.DefaultClient.Do(req)
+	metrics.RequestStarted(resourceType, tenant, http.MethodPost)
+	requestStartTs := time.Now()
+	res, err := request.Client.Do(req)
+	latency := time.Since(requestStartTs)
 	if err != nil {
+		metrics.ObserveRequest(resourceType, tenant, http.MethodPost, 0, latency, false)
+
 		logger.Logger.Errorw(
-			"could not send the creation request. Skipping...",
+			"could not send the creation request",
 			zap.Error(err),
 			zap.String("resource_type", resourceType),
 			zap.String("tenant", tenant),
@@ -691,13 +1162,19 @@ func sendCreationRequest(resourceType string, tenant string, url string, body []
 			zap.Any("body", json.RawMessage(body)),
 		)
 
+		// Connection errors and timeouts are usually transient. However, the back end might have created the resource
+		// before the request timed out, in which case the retry creates a duplicate which never makes it to the
+		// manifest, since we never get its ID.
 		<-config.ConcurrentRequests
-		return nil, false
+		return creationAttempt{IsRetryable: true}
 	}
 
 	// Request is done, we can free one slot in the channel.
 	<-config.ConcurrentRequests
 
+	metrics.ObserveRequest(resourceType, tenant, http.MethodPost, res.StatusCode, latency, res.StatusCode == http.StatusCreated)
+	stats.RecordLatency(resourceType, latency)
+
 	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
 		logger.Logger.Errorw(
@@ -722,8 +1199,8 @@ func sendCreationRequest(resourceType string, tenant string, url string, body []
 
 	if res.StatusCode != http.StatusCreated {
 		logger.Logger.Errorw(
-			"unexpected status code when creating a resource. Skipping...",
-			zap.Int("want_status_code", http.StatusOK),
+			"unexpected status code when creating a resource",
+			zap.Int("want_status_code", http.StatusCreated),
 			zap.Any("response_body", json.RawMessage(resBody)),
 			zap.Int("got_status_code", res.StatusCode),
 			zap.String("resource_type", resourceType),
@@ -731,8 +1208,13 @@ func sendCreationRequest(resourceType string, tenant string, url string, body []
 			zap.String("url", url),
 			zap.Any("body", json.RawMessage(body)),
 		)
-		return nil, false
+
+		return creationAttempt{
+			Latency:     latency,
+			IsRetryable: isRetryableStatusCode(res.StatusCode),
+			RetryAfter:  parseRetryAfter(res.Header.Get("Retry-After")),
+		}
 	}
 
-	return resBody, true
+	return creationAttempt{Body: resBody, Latency: latency, IsSuccess: true}
 }
@@ -0,0 +1,129 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// Entry represents a resource that was created in the back end.
+type Entry struct {
+	Tenant            string  `json:"tenant"`
+	ResourceType      string  `json:"resource_type"`
+	Id                string  `json:"id"`
+	ParentType        string  `json:"parent_type,omitempty"`
+	ParentId          string  `json:"parent_id,omitempty"`
+	SourceTypeId      string  `json:"source_type_id,omitempty"`
+	SourceTypeName    string  `json:"source_type_name,omitempty"`
+	ApplicationTypeId string  `json:"application_type_id,omitempty"`
+	AuthType          string  `json:"auth_type,omitempty"`
+	LatencyMs         float64 `json:"latency_ms"`
+}
+
+// file is the manifest file the entries are written to. The entries are not buffered, so that they are not lost if the
+// program dies, and the manifest always covers every resource the checkpoint does.
+var file *os.File
+
+// mutex makes sure that the entries written from the different goroutines don't get interleaved.
+var mutex sync.Mutex
+
+// InitializeManifest creates the manifest file if the user specified one. Otherwise, the recorded entries are simply
+// discarded. When resuming a previous run, the entries are appended to the existing manifest instead.
+func InitializeManifest() {
+	if config.ManifestFile == "" {
+		return
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if config.ResumeFile != "" {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(config.ManifestFile, flags, 0644)
+	if err != nil {
+		logger.Logger.Fatalw(
+			"could not create the manifest file",
+			zap.Error(err),
+			zap.String("manifest_file", config.ManifestFile),
+		)
+	}
+
+	file = f
+}
+
+// Record writes the given entry as a new JSON line in the manifest file.
+func Record(entry Entry) {
+	if file == nil {
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not marshal the manifest entry into JSON",
+			zap.Error(err),
+			zap.Any("entry", entry),
+		)
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logger.Logger.Errorw(
+			"could not write the entry to the manifest file",
+			zap.Error(err),
+			zap.Any("entry", entry),
+		)
+	}
+}
+
+// CloseManifest closes the manifest file.
+func CloseManifest() {
+	if file == nil {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err := file.Close(); err != nil {
+		logger.Logger.Errorw("could not close the manifest file", zap.Error(err))
+	}
+
+	file = nil
+}
+
+// ReadManifest reads all the entries from the given manifest file.
+func ReadManifest(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("could not parse line %d of the manifest: %w", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// requestLabels are the labels every request metric is broken down by.
+var requestLabels = []string{"resource_type", "tenant", "method", "status_code"}
+
+// inFlightLabels are the labels the in flight requests are broken down by. They don't have a status code yet.
+var inFlightLabels = []string{"resource_type", "tenant", "method"}
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sources_populator_requests_total",
+			Help: "Number of requests sent to the Sources API.",
+		},
+		requestLabels,
+	)
+
+	requestErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sources_populator_request_errors_total",
+			Help: "Number of requests that did not have the expected outcome.",
+		},
+		requestLabels,
+	)
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "sources_populator_request_duration_seconds",
+			Help:    "Latency of the requests sent to the Sources API.",
+			Buckets: prometheus.DefBuckets,
+		},
+		requestLabels,
+	)
+
+	requestsInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "sources_populator_requests_in_flight",
+			Help: "Number of requests currently being sent to the Sources API.",
+		},
+		inFlightLabels,
+	)
+)
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestErrorsTotal, requestDuration, requestsInFlight)
+}
+
+// InitializeMetrics starts the HTTP listener that exposes the metrics in the Prometheus text format, if the user
+// specified an address for it. Otherwise, the metrics are still collected but never exposed.
+func InitializeMetrics() {
+	if config.MetricsAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	go func() {
+		if err := http.ListenAndServe(config.MetricsAddress, mux); err != nil {
+			logger.Logger.Fatalw(
+				"could not start the metrics listener",
+				zap.Error(err),
+				zap.String("metrics_address", config.MetricsAddress),
+			)
+		}
+	}()
+
+	logger.Logger.Infow("Metrics exposed", zap.String("url", fmt.Sprintf("http://%s/metrics", config.MetricsAddress)))
+}
+
+// RequestStarted records that a request, which holds a slot in the throttler, is about to be sent to the back end. Every
+// call must be followed by a call to "ObserveRequest" with the same resource type, tenant and method.
+func RequestStarted(resourceType string, tenant string, method string) {
+	requestsInFlight.WithLabelValues(resourceType, tenant, method).Inc()
+}
+
+// ObserveRequest records a request sent to the back end, which is no longer in flight. The status code is zero when the
+// request could not be sent at all, in which case the latency is not recorded, and "isSuccess" is false when the
+// request did not have the expected outcome.
+func ObserveRequest(resourceType string, tenant string, method string, statusCode int, latency time.Duration, isSuccess bool) {
+	requestsInFlight.WithLabelValues(resourceType, tenant, method).Dec()
+
+	labels := prometheus.Labels{
+		"resource_type": resourceType,
+		"tenant":        tenant,
+		"method":        method,
+		"status_code":   strconv.Itoa(statusCode),
+	}
+
+	// The errors are exposed for every set of labels, even when there are none, so that the error rates can be
+	// computed right away.
+	requestsTotal.With(labels).Inc()
+	requestErrors := requestErrorsTotal.With(labels)
+	if !isSuccess {
+		requestErrors.Inc()
+	}
+
+	if statusCode == 0 {
+		return
+	}
+
+	requestDuration.With(labels).Observe(latency.Seconds())
+}
@@ -0,0 +1,113 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// Request represents a request that would have been sent to the back end.
+type Request struct {
+	ResourceType string          `json:"resource_type"`
+	Tenant       string          `json:"tenant"`
+	Method       string          `json:"method"`
+	Url          string          `json:"url"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// file is the output file, when the user specified one.
+var file *os.File
+
+// lastId is used to generate the fake IDs for the planned resources.
+var lastId uint64
+
+// mutex makes sure that the requests written from the different goroutines don't get interleaved.
+var mutex sync.Mutex
+
+// writer is the buffered writer for the plan's output.
+var writer *bufio.Writer
+
+// InitializePlan sets up the output of the planned requests, which is either the file the user specified or the
+// standard output.
+func InitializePlan() {
+	var out io.Writer = os.Stdout
+	if config.DryRunOutputFile != "" {
+		f, err := os.Create(config.DryRunOutputFile)
+		if err != nil {
+			logger.Logger.Fatalw(
+				"could not create the dry run output file",
+				zap.Error(err),
+				zap.String("dry_run_output_file", config.DryRunOutputFile),
+			)
+		}
+
+		file = f
+		out = f
+	}
+
+	writer = bufio.NewWriter(out)
+}
+
+// Record writes the given creation request to the plan's output, and returns a fake response body with a generated ID
+// so that the sub resources of the planned resource can be planned too.
+func Record(resourceType string, tenant string, url string, body []byte) []byte {
+	request := Request{
+		ResourceType: resourceType,
+		Tenant:       tenant,
+		Method:       http.MethodPost,
+		Url:          url,
+		Body:         body,
+	}
+
+	line, err := json.Marshal(request)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not marshal the planned request into JSON",
+			zap.Error(err),
+			zap.Any("request", request),
+		)
+	} else {
+		mutex.Lock()
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			logger.Logger.Errorw(
+				"could not write the planned request",
+				zap.Error(err),
+				zap.Any("request", request),
+			)
+		}
+		mutex.Unlock()
+	}
+
+	return []byte(fmt.Sprintf(`{"id":"%d"}`, atomic.AddUint64(&lastId, 1)))
+}
+
+// ClosePlan flushes any buffered requests and closes the output file, if any.
+func ClosePlan() {
+	if writer == nil {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if err := writer.Flush(); err != nil {
+		logger.Logger.Errorw("could not flush the dry run output", zap.Error(err))
+	}
+
+	if file != nil {
+		if err := file.Close(); err != nil {
+			logger.Logger.Errorw("could not close the dry run output file", zap.Error(err))
+		}
+	}
+
+	writer = nil
+}
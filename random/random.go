@@ -0,0 +1,55 @@
+package random
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/google/uuid"
+)
+
+// seed is the seed the random generators are derived from. It is only used when isSeeded is true.
+var seed string
+
+// isSeeded is true when the user asked for a deterministic run.
+var isSeeded bool
+
+// SetSeed makes every generator that gets created afterwards deterministic. An empty seed keeps the generators random.
+func SetSeed(s string) {
+	seed = s
+	isSeeded = s != ""
+}
+
+// New returns a random generator for the entity identified by the given key, such as "<tenant>/source/3". When a seed
+// was set, the generator is derived from the seed and the key, which makes the entity get the very same random values
+// on every run regardless of the order in which the entities are created. Otherwise, the generator is randomly seeded.
+//
+// The returned generator must not be shared between goroutines.
+func New(key string) *rand.Rand {
+	if !isSeeded {
+		var buf [8]byte
+		if _, err := cryptorand.Read(buf[:]); err != nil {
+			// The crypto reader is not expected to fail, but if it does, the key is still a good enough seed for
+			// random data.
+			return rand.New(rand.NewSource(hash(key)))
+		}
+
+		return rand.New(rand.NewSource(int64(binary.LittleEndian.Uint64(buf[:]))))
+	}
+
+	return rand.New(rand.NewSource(hash(seed + "/" + key)))
+}
+
+// NewUUID returns a version 4 UUID generated from the given random generator.
+func NewUUID(r *rand.Rand) (uuid.UUID, error) {
+	return uuid.NewRandomFromReader(r)
+}
+
+// hash returns the FNV-1a hash of the given string.
+func hash(s string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return int64(h.Sum64())
+}
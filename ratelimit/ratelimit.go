@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+)
+
+// bucket is a token bucket which holds at most one token, so that the requests are sent at a fixed arrival rate
+// instead of in bursts. The tokens can go negative, which represents the requests that are already waiting for their
+// turn.
+type bucket struct {
+	mutex sync.Mutex
+	// getRate returns the rate, in tokens per second, for the given elapsed time since the bucket was created.
+	getRate   func(elapsed time.Duration) float64
+	lastTs    time.Time
+	startTs   time.Time
+	tokens    float64
+	maxTokens float64
+}
+
+// globalBucket limits the overall rate of the creation requests. It is nil when there is no overall rate limit.
+var globalBucket *bucket
+
+// resourceBuckets limits the rate of the creation requests of the resource types which have a specific rate limit.
+var resourceBuckets = make(map[string]*bucket)
+
+// InitializeRateLimiters sets up the configured rate limits. The ramp up of the overall rate limit, if any, starts
+// right away.
+func InitializeRateLimiters() {
+	if config.RateLimit > 0 {
+		globalBucket = newBucket(getRampUpRate)
+	}
+
+	for _, resourceType := range []string{"application", "authentication", "endpoint", "rhcConnection", "source"} {
+		if rateLimit := config.GetRateLimit(resourceType); rateLimit > 0 {
+			resourceBuckets[resourceType] = newBucket(func(time.Duration) float64 {
+				return rateLimit
+			})
+		}
+	}
+}
+
+// Wait blocks until a creation request for the given resource type can be sent without exceeding either the
+// resource type's rate limit or the overall one.
+func Wait(resourceType string) {
+	if b, ok := resourceBuckets[resourceType]; ok {
+		b.take()
+	}
+
+	if globalBucket != nil {
+		globalBucket.take()
+	}
+}
+
+// newBucket creates a full bucket which gets refilled at the rate that the given function returns.
+func newBucket(getRate func(elapsed time.Duration) float64) *bucket {
+	now := time.Now()
+
+	return &bucket{
+		getRate:   getRate,
+		lastTs:    now,
+		startTs:   now,
+		tokens:    1,
+		maxTokens: 1,
+	}
+}
+
+// take takes a token from the bucket, and sleeps until the token would have been available if there wasn't one.
+func (b *bucket) take() {
+	b.mutex.Lock()
+
+	now := time.Now()
+	rate := b.getRate(now.Sub(b.startTs))
+
+	b.tokens = math.Min(b.maxTokens, b.tokens+now.Sub(b.lastTs).Seconds()*rate)
+	b.lastTs = now
+	b.tokens--
+
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / rate * float64(time.Second))
+	}
+
+	b.mutex.Unlock()
+
+	time.Sleep(delay)
+}
+
+// getRampUpRate returns the overall rate limit for the given elapsed time since the beginning of the run, which goes
+// linearly from the initial rate to the configured rate limit during the ramp up.
+func getRampUpRate(elapsed time.Duration) float64 {
+	if config.RateLimitRampUpDuration == 0 || elapsed >= config.RateLimitRampUpDuration {
+		return config.RateLimit
+	}
+
+	progress := float64(elapsed) / float64(config.RateLimitRampUpDuration)
+
+	return config.RateLimitRampUpFrom + (config.RateLimit-config.RateLimitRampUpFrom)*progress
+}
@@ -0,0 +1,115 @@
+package request
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// DefaultTenant is the tenant used for the requests which don't belong to any tenant in particular, such as the health
+// check or the catalogue requests. It is a "x-rh-identity" with an "account number: 12345".
+const DefaultTenant = "ewogICAgImlkZW50aXR5IjogewogICAgICAgICJhY2NvdW50X251bWJlciI6ICIxMjM0NSIKICAgIH0KfQ=="
+
+// Client is the HTTP client configured with the TLS settings, which every request to the back end goes through.
+var Client = http.DefaultClient
+
+// tenantIdentifiers caches the decoded identifiers of the tenants, so that the identities don't have to be decoded on
+// every request.
+var tenantIdentifiers sync.Map
+
+// InitializeClient builds the shared HTTP client with the configured TLS settings.
+func InitializeClient() {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.TlsInsecureSkipVerify,
+		ServerName:         config.TlsServerName,
+	}
+
+	if config.TlsCaBundle != "" {
+		// Trust the given certificate authorities on top of the system ones.
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+
+		bundle, err := os.ReadFile(config.TlsCaBundle)
+		if err != nil {
+			logger.Logger.Fatalw("could not read the CA bundle", zap.Error(err), zap.String("ca_bundle", config.TlsCaBundle))
+		}
+
+		if !rootCAs.AppendCertsFromPEM(bundle) {
+			logger.Logger.Fatalw("the CA bundle does not contain any valid PEM certificates", zap.String("ca_bundle", config.TlsCaBundle))
+		}
+
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if config.TlsClientCert != "" {
+		certificate, err := tls.LoadX509KeyPair(config.TlsClientCert, config.TlsClientKey)
+		if err != nil {
+			logger.Logger.Fatalw(
+				"could not load the client certificate",
+				zap.Error(err),
+				zap.String("client_cert", config.TlsClientCert),
+				zap.String("client_key", config.TlsClientKey),
+			)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// Keep as many idle connections as concurrent requests we may send, so that the connections get reused instead of
+	// opening new ones for most of the requests.
+	transport.MaxIdleConnsPerHost = cap(config.ConcurrentRequests)
+
+	Client = &http.Client{Transport: transport}
+}
+
+// SetHeaders sets the "Accept" header and the authentication headers for the given tenant, which is a base64 encoded
+// XRHID. Depending on the authentication mode, the tenant is either sent as is in the "x-rh-identity" header, or its
+// org ID and account number are sent along with the pre shared key.
+func SetHeaders(req *http.Request, tenant string) {
+	req.Header.Set("Accept", "application/json")
+
+	if config.AuthMode != config.AuthModePsk {
+		req.Header.Set("x-rh-identity", tenant)
+		return
+	}
+
+	req.Header.Set("x-rh-sources-psk", config.Psk)
+
+	identifiers := getTenantIdentifiers(tenant)
+	if identifiers.OrgId != "" {
+		req.Header.Set("x-rh-sources-org-id", identifiers.OrgId)
+	}
+	if identifiers.AccountNumber != "" {
+		req.Header.Set("x-rh-sources-account-number", identifiers.AccountNumber)
+	}
+}
+
+// getTenantIdentifiers returns the decoded identifiers of the given tenant.
+func getTenantIdentifiers(tenant string) config.TenantIdentifiers {
+	if identifiers, ok := tenantIdentifiers.Load(tenant); ok {
+		return identifiers.(config.TenantIdentifiers)
+	}
+
+	identifiers, err := config.GetTenantIdentifiers(tenant)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not get the tenant's identifiers for the PSK headers",
+			zap.Error(err),
+			zap.String("tenant", tenant),
+		)
+	}
+
+	tenantIdentifiers.Store(tenant, identifiers)
+
+	return identifiers
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+)
+
+// getRetryDelay returns how long to wait before the given retry, which starts at zero. The delay grows exponentially
+// from the configured base delay up to the configured maximum, and a random jitter is applied so that the failed
+// requests don't all get retried at the same time. When the back end asked for a specific delay, that one is honored
+// instead, up to the configured maximum so that a misbehaving back end or proxy cannot stall the run.
+func getRetryDelay(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > config.RetryMaxDelay {
+			return config.RetryMaxDelay
+		}
+
+		return retryAfter
+	}
+
+	delay := config.RetryMaxDelay
+	if retry < 32 {
+		if exponential := config.RetryBaseDelay << retry; exponential > 0 && exponential < delay {
+			delay = exponential
+		}
+	}
+
+	// Wait at least half of the delay, plus a random amount up to the other half.
+	half := delay / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableStatusCode returns true when the given status code signals a transient error, which are the server errors
+// and the "too many requests" responses.
+func isRetryableStatusCode(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter parses the value of a "Retry-After" header, which is either a number of seconds or an HTTP date. It
+// returns zero when the header is empty or not valid.
+func parseRetryAfter(header string) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
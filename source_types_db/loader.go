@@ -0,0 +1,238 @@
+package source_types_db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// cataloguePageLimit is the number of source types or application types we ask the back end for on each request.
+const cataloguePageLimit = 100
+
+// Catalogue holds the source types and application types in the same format the back end returns them, which is also
+// the format of the local source types file.
+type Catalogue struct {
+	SourceTypes      []SourceTypeResponse      `json:"source_types"`
+	ApplicationTypes []ApplicationTypeResponse `json:"application_types"`
+}
+
+// AuthenticationResponse, SchemaResponse and SourceTypeResponse are the structs we unmarshal the source types into. We
+// cannot unmarshal the payload into a "[]model.SourceType" because the IDs come as strings and the model has an int id.
+// Therefore, the "Unmarshal" function fails.
+type AuthenticationResponse struct {
+	Type string `json:"type"`
+}
+
+type SchemaResponse struct {
+	Authentication []AuthenticationResponse `json:"authentication"`
+}
+
+type SourceTypeResponse struct {
+	Id     string         `json:"id"`
+	Name   string         `json:"name"`
+	Schema SchemaResponse `json:"schema"`
+}
+
+// ApplicationTypeResponse is the struct we unmarshal the application types into.
+type ApplicationTypeResponse struct {
+	Id                           string              `json:"id"`
+	Name                         string              `json:"name"`
+	SupportedSourceTypes         []string            `json:"supported_source_types"`
+	SupportedAuthenticationTypes map[string][]string `json:"supported_authentication_types"`
+}
+
+// Loader loads the source types and application types catalogue from somewhere, so that a "SourceTypesDb" can be
+// built from it.
+type Loader interface {
+	Load() (Catalogue, error)
+}
+
+// HttpLoader loads the catalogue from a back end, by paginating through its source types and application types
+// collections.
+type HttpLoader struct {
+	SourceTypesUrl      string
+	ApplicationTypesUrl string
+
+	// Client sends the requests to the back end, and SetHeaders sets the identity headers on them.
+	Client     *http.Client
+	SetHeaders func(req *http.Request)
+
+	// Timeout is how long each request to the back end is given.
+	Timeout time.Duration
+}
+
+// FileLoader loads the catalogue from a local source types file.
+type FileLoader struct {
+	Path string
+}
+
+// MemoryLoader "loads" the catalogue it holds.
+type MemoryLoader struct {
+	Catalogue Catalogue
+}
+
+// NewLoader returns a loader for the given local source types file if there is one, or a loader which fetches the
+// catalogue from the back end's given collections otherwise.
+func NewLoader(sourceTypesFile string, sourceTypesUrl string, applicationTypesUrl string, client *http.Client, setHeaders func(req *http.Request), timeout time.Duration) Loader {
+	if sourceTypesFile != "" {
+		return FileLoader{Path: sourceTypesFile}
+	}
+
+	return HttpLoader{
+		SourceTypesUrl:      sourceTypesUrl,
+		ApplicationTypesUrl: applicationTypesUrl,
+		Client:              client,
+		SetHeaders:          setHeaders,
+		Timeout:             timeout,
+	}
+}
+
+// Load fetches all the source types and application types from the back end. It returns an error if the number of
+// fetched types doesn't match the count the back end reports, since generating data from a partial catalogue would
+// silently skip types.
+func (l HttpLoader) Load() (Catalogue, error) {
+	var catalogue Catalogue
+
+	sourceTypes, err := l.getCatalogue(l.SourceTypesUrl, "source types")
+	if err != nil {
+		return Catalogue{}, err
+	}
+
+	for _, item := range sourceTypes {
+		var st SourceTypeResponse
+		if err := json.Unmarshal(item, &st); err != nil {
+			return Catalogue{}, fmt.Errorf(`could not unmarshal the source type %s: %w`, item, err)
+		}
+
+		catalogue.SourceTypes = append(catalogue.SourceTypes, st)
+	}
+
+	applicationTypes, err := l.getCatalogue(l.ApplicationTypesUrl, "application types")
+	if err != nil {
+		return Catalogue{}, err
+	}
+
+	for _, item := range applicationTypes {
+		var appType ApplicationTypeResponse
+		if err := json.Unmarshal(item, &appType); err != nil {
+			return Catalogue{}, fmt.Errorf(`could not unmarshal the application type %s: %w`, item, err)
+		}
+
+		catalogue.ApplicationTypes = append(catalogue.ApplicationTypes, appType)
+	}
+
+	return catalogue, nil
+}
+
+// Load reads the source types and the application types from the file.
+func (l FileLoader) Load() (Catalogue, error) {
+	content, err := os.ReadFile(l.Path)
+	if err != nil {
+		return Catalogue{}, fmt.Errorf(`could not read the source types file "%s": %w`, l.Path, err)
+	}
+
+	var catalogue Catalogue
+	if err := json.Unmarshal(content, &catalogue); err != nil {
+		return Catalogue{}, fmt.Errorf(`could not unmarshal the source types file "%s": %w`, l.Path, err)
+	}
+
+	return catalogue, nil
+}
+
+// Load returns the catalogue the loader holds.
+func (l MemoryLoader) Load() (Catalogue, error) {
+	return l.Catalogue, nil
+}
+
+// writeCatalogueFile writes the given catalogue to the given file, so that it can be used as the source types file of
+// later runs.
+func writeCatalogueFile(path string, catalogue Catalogue) error {
+	content, err := json.MarshalIndent(catalogue, "", "  ")
+	if err != nil {
+		return fmt.Errorf(`could not marshal the source types and application types to JSON: %w`, err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return fmt.Errorf(`could not write the source types export file "%s": %w`, path, err)
+	}
+
+	return nil
+}
+
+// cataloguePage is a page of a catalogue collection, which comes in the {"meta": {...}, "data": [{...}]} form.
+type cataloguePage struct {
+	Meta struct {
+		Count int `json:"count"`
+	} `json:"meta"`
+	Data []json.RawMessage `json:"data"`
+}
+
+// getCatalogue fetches all the items of the given catalogue collection by paginating through it, since the back end's
+// default page limit might be lower than the catalogue's size.
+func (l HttpLoader) getCatalogue(collectionUrl string, description string) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+	var count int
+	for offset := 0; ; {
+		page, err := l.getCataloguePage(fmt.Sprintf("%s?limit=%d&offset=%d", collectionUrl, cataloguePageLimit, offset), description)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Data...)
+		count = page.Meta.Count
+
+		offset += len(page.Data)
+		if len(page.Data) == 0 || offset >= count {
+			break
+		}
+	}
+
+	if len(items) != count {
+		return nil, fmt.Errorf(`fetched %d %s, but the back end reports %d of them`, len(items), description, count)
+	}
+
+	return items, nil
+}
+
+// getCataloguePage sends a request to fetch the given page of a catalogue collection.
+func (l HttpLoader) getCataloguePage(pageUrl string, description string) (cataloguePage, error) {
+	// Every page gets the whole timeout, since a large catalogue takes a few pages.
+	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageUrl, nil)
+	if err != nil {
+		return cataloguePage{}, fmt.Errorf(`could not create the request for the %s: %w`, description, err)
+	}
+
+	l.SetHeaders(req)
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return cataloguePage{}, fmt.Errorf(`could not get the %s: %w`, description, err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cataloguePage{}, fmt.Errorf(`could not read the body from the "get %s" response: %w`, description, err)
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		return cataloguePage{}, fmt.Errorf(`could not close the body from the "get %s" response: %w`, description, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return cataloguePage{}, fmt.Errorf(`unexpected status code %d when getting the %s: %s`, resp.StatusCode, description, body)
+	}
+
+	var page cataloguePage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return cataloguePage{}, fmt.Errorf(`could not unmarshal the %s page %s: %w`, description, body, err)
+	}
+
+	return page, nil
+}
@@ -1,333 +1,525 @@
 package source_types_db
 
 import (
-	"context"
-	"encoding/json"
-	"io"
+	"fmt"
 	"math/rand"
-	"net/http"
-	"time"
+	"path"
+	"sort"
+	"sync"
 
-	"github.com/MikelAlejoBR/sources-database-populator/config"
 	"github.com/MikelAlejoBR/sources-database-populator/logger"
 	"go.uber.org/zap"
 )
 
-// sourceNameId holds the name of the source type and its corresponding id, for an easy and quick lookup of "get the ID
-// of this source name".
-var sourceNameId = make(map[string]string)
-
-// sourceTypes "is the database" which contains all the source types with their corresponding authentications and
-// compatible applications.
-var sourceTypes = make(map[string]SourceType)
-
-// sourceTypesKeys is a helper list which will allow us getting random source types easier.
-var sourceTypesKeys []string
-
 // SourceType is the structure we will use to store the source type, its compatible authentications, its compatible
 // applications, and the compatible authentications for those applications.
 type SourceType struct {
-	Id                         string
-	Name                       string
-	CompatibleAuthentications  []string
-	CompatibleApplicationTypes map[string]ApplicationType
+	Id                         string                     `json:"id"`
+	Name                       string                     `json:"name"`
+	CompatibleAuthentications  []string                   `json:"compatible_authentications"`
+	CompatibleApplicationTypes map[string]ApplicationType `json:"compatible_application_types"`
 }
 
 // ApplicationType holds the structure for an application and its compatible authentication types.
 type ApplicationType struct {
-	Id                        string
-	CompatibleAuthentications []string
+	Id                        string   `json:"id"`
+	Name                      string   `json:"name"`
+	CompatibleAuthentications []string `json:"compatible_authentications"`
+}
+
+// Options holds the filters that get applied to the catalogue when it is stored, and how the sources get distributed
+// among the stored source types.
+type Options struct {
+	// IncludeSourceTypes and IncludeApplicationTypes hold the only types to store. When they are empty, every type is
+	// stored.
+	IncludeSourceTypes      []string
+	IncludeApplicationTypes []string
+
+	// ExcludeSourceTypes and ExcludeApplicationTypes hold the types not to store, even if they are included.
+	ExcludeSourceTypes      []string
+	ExcludeApplicationTypes []string
+
+	// SourceTypeCounts holds the exact number of sources to create per source type name, if any.
+	SourceTypeCounts map[string]int
+
+	// SourceTypeWeights holds the weights the random source types are picked with by source type name, if any.
+	SourceTypeWeights map[string]float64
+
+	// ExportFile is the file the loaded catalogue gets exported to, before applying the filters, if any.
+	ExportFile string
+}
+
+// SourceTypesDb is the in memory database which holds the source types with their corresponding authentications and
+// compatible applications. It is safe for concurrent use, and several of them can coexist, for example one per back
+// end.
+type SourceTypesDb struct {
+	mutex sync.RWMutex
+
+	// options holds the filters, the counts and the weights of the database, which don't change after it is created.
+	options Options
+
+	// sourceNameId holds the name of the source type and its corresponding id, for an easy and quick lookup of "get
+	// the ID of this source name".
+	sourceNameId map[string]string
+
+	// sourceTypes "is the database" which contains all the source types by their ids.
+	sourceTypes map[string]SourceType
+
+	// sourceTypesKeys is a helper list which will allow us getting random source types easier. It is sorted so that
+	// the same random generator always picks the same source types.
+	sourceTypesKeys []string
+
+	// countedSourceTypesKeys holds a source type id per source to create for each tenant when the user specified the
+	// exact number of sources per source type. The ids are grouped by source type, in the order of the source types'
+	// names.
+	countedSourceTypesKeys []string
+
+	// issues holds the integrity issues found in the catalogue.
+	issues []CatalogueIssue
 }
 
-// SourceTypesDb is the structure we will use for accessing the database.
-type SourceTypesDb struct{}
+// NewSourceTypesDb returns an empty database which applies the given options to the catalogues it stores.
+func NewSourceTypesDb(options Options) *SourceTypesDb {
+	return &SourceTypesDb{
+		options:      options,
+		sourceNameId: make(map[string]string),
+		sourceTypes:  make(map[string]SourceType),
+	}
+}
 
 // CreateSourceType creates a new source type from the "sourceTypeId" and the "sourceTypeName".
-func (sdb SourceTypesDb) CreateSourceType(sourceTypeId string, sourceTypeName string) {
+func (sdb *SourceTypesDb) CreateSourceType(sourceTypeId string, sourceTypeName string) {
+	sdb.mutex.Lock()
+	defer sdb.mutex.Unlock()
+
 	// Store the source type name and id for an easier lookup afterwards. This will be useful when receiving the
 	// "get application types" response, where we will the sources they are compatible with by their name. That way it
 	// will be easier to link them to source ids.
-	sourceNameId[sourceTypeName] = sourceTypeId
+	sdb.sourceNameId[sourceTypeName] = sourceTypeId
 
-	sourceTypes[sourceTypeId] = SourceType{
+	sdb.sourceTypes[sourceTypeId] = SourceType{
 		Id:   sourceTypeId,
 		Name: sourceTypeName,
 	}
 }
 
 // AddAuthenticationType adds a compatible authentication type to the given source type id.
-func (sdb SourceTypesDb) AddAuthenticationType(sourceTypeId string, authenticationType string) {
+func (sdb *SourceTypesDb) AddAuthenticationType(sourceTypeId string, authenticationType string) {
+	sdb.mutex.Lock()
+	defer sdb.mutex.Unlock()
+
 	// Get the source type.
-	sourceType := sourceTypes[sourceTypeId]
+	sourceType := sdb.sourceTypes[sourceTypeId]
 
 	// Append the authentication type.
 	sourceType.CompatibleAuthentications = append(sourceType.CompatibleAuthentications, authenticationType)
 
 	// Make sure to overwrite the source type as otherwise it won't be saved.
-	sourceTypes[sourceTypeId] = sourceType
+	sdb.sourceTypes[sourceTypeId] = sourceType
 }
 
 // AddCompatibleApplicationType adds the application type ID to all the compatible source types of the database. It
 // also adds the supported authentication types as compatible authentications for the application.
-func (sdb SourceTypesDb) AddCompatibleApplicationType(applicationTypeId string, supportedSourceTypes []string, supportedAuthenticationTypes map[string][]string) {
+func (sdb *SourceTypesDb) AddCompatibleApplicationType(applicationTypeId string, applicationTypeName string, supportedSourceTypes []string, supportedAuthenticationTypes map[string][]string) {
+	sdb.mutex.Lock()
+	defer sdb.mutex.Unlock()
+
 	for _, sst := range supportedSourceTypes {
-		// Fetch the source type id by its name.
-		sstId := sourceNameId[sst]
-
-		// Fetch the source type by its ID.
-		sourceType := sourceTypes[sstId]
-		// In order to avoid nil pointer dereferences, make sure that the map is at least initialized.
-		if sourceType.CompatibleApplicationTypes == nil {
-			sourceType.CompatibleApplicationTypes = make(map[string]ApplicationType)
+		// Fetch the source type id by its name. The source types which don't exist are skipped, since otherwise the
+		// application type would be added to a source type with an empty id.
+		sstId, ok := sdb.sourceNameId[sst]
+		if !ok {
+			continue
 		}
 
+		// Fetch the source type by its ID. The compatible application types get copied, since the source types that
+		// were previously returned by the getters share the map.
+		sourceType := sdb.sourceTypes[sstId]
+		compatibleApplicationTypes := make(map[string]ApplicationType, len(sourceType.CompatibleApplicationTypes)+1)
+		for id, appType := range sourceType.CompatibleApplicationTypes {
+			compatibleApplicationTypes[id] = appType
+		}
+		sourceType.CompatibleApplicationTypes = compatibleApplicationTypes
+
 		// The application type might already have been added to the source type. In that case append the
 		// authentications to the ones that already existed.
 		appType, ok := sourceType.CompatibleApplicationTypes[applicationTypeId]
 		if ok {
 			appType.CompatibleAuthentications = append(appType.CompatibleAuthentications, supportedAuthenticationTypes[sourceType.Name]...)
-
-			// Overwrite the source type to store the changes.
-			sourceTypes[sstId] = sourceType
 		} else {
 			// Create the brand new application type.
-			sourceType.CompatibleApplicationTypes[applicationTypeId] = ApplicationType{
+			appType = ApplicationType{
 				Id:                        applicationTypeId,
+				Name:                      applicationTypeName,
 				CompatibleAuthentications: supportedAuthenticationTypes[sourceType.Name],
 			}
-
-			// Overwrite the source type to store the changes.
-			sourceTypes[sstId] = sourceType
 		}
+		sourceType.CompatibleApplicationTypes[applicationTypeId] = appType
+
+		// Overwrite the source type to store the changes.
+		sdb.sourceTypes[sstId] = sourceType
 	}
 }
 
 // GetRandomAuthenticationTypeForApplication gets a random authentication type that is compatible with the provided
 // application type id, which in turn is compatible with the provided source type id as well.
-func (sdb SourceTypesDb) GetRandomAuthenticationTypeForApplication(sourceTypeId string, applicationTypeId string) string {
-	st := sourceTypes[sourceTypeId]
+func (sdb *SourceTypesDb) GetRandomAuthenticationTypeForApplication(r *rand.Rand, sourceTypeId string, applicationTypeId string) string {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	st := sdb.sourceTypes[sourceTypeId]
 	appTypes := st.CompatibleApplicationTypes[applicationTypeId]
 
 	// The "azure" and "google" source types from the cloud meter application don't have a defined authentication, so
 	// in this case we can return a fixed authentication type.
-	if appTypes.CompatibleAuthentications == nil {
+	if len(appTypes.CompatibleAuthentications) == 0 {
 		return "cloud-meter-app-does-not-have-azure-or-google-supported-authentication-types"
 	}
-	idx := rand.Intn(len(appTypes.CompatibleAuthentications))
+	idx := r.Intn(len(appTypes.CompatibleAuthentications))
 
 	return appTypes.CompatibleAuthentications[idx]
 }
 
-// GetRandomAuthenticationTypeForSource gets a random compatible authentication type for the given source type id.
-func (sdb SourceTypesDb) GetRandomAuthenticationTypeForSource(sourceTypeId string) string {
-	st := sourceTypes[sourceTypeId]
+// GetRandomAuthenticationTypeForSource gets a random compatible authentication type for the given source type id, or an
+// empty string if the source type doesn't support any.
+func (sdb *SourceTypesDb) GetRandomAuthenticationTypeForSource(r *rand.Rand, sourceTypeId string) string {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
 
-	idx := rand.Intn(len(st.CompatibleAuthentications))
+	st := sdb.sourceTypes[sourceTypeId]
+	if len(st.CompatibleAuthentications) == 0 {
+		return ""
+	}
+
+	idx := r.Intn(len(st.CompatibleAuthentications))
 
 	return st.CompatibleAuthentications[idx]
 }
 
-// GetApplicationTypes returns the list of the compatible application types for the given source.
-func (sdb SourceTypesDb) GetApplicationTypes(sourceTypeId string) []ApplicationType {
-	st := sourceTypes[sourceTypeId]
+// HasAuthenticationTypes returns true if the given source type id supports at least one authentication type.
+func (sdb *SourceTypesDb) HasAuthenticationTypes(sourceTypeId string) bool {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	return len(sdb.sourceTypes[sourceTypeId].CompatibleAuthentications) > 0
+}
+
+// GetApplicationTypes returns the list of the compatible application types for the given source, sorted by their IDs.
+func (sdb *SourceTypesDb) GetApplicationTypes(sourceTypeId string) []ApplicationType {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	st := sdb.sourceTypes[sourceTypeId]
 
 	var applicationTypes = make([]ApplicationType, 0, len(st.CompatibleApplicationTypes))
 	for _, appType := range st.CompatibleApplicationTypes {
 		applicationTypes = append(applicationTypes, appType)
 	}
 
+	sort.Slice(applicationTypes, func(i, j int) bool {
+		return applicationTypes[i].Id < applicationTypes[j].Id
+	})
+
 	return applicationTypes
 }
 
-// GetRandomSourceType returns a random source type from the database.
-func (sdb SourceTypesDb) GetRandomSourceType() SourceType {
-	// Initialize the array if it is not initialized already.
-	if sourceTypesKeys == nil {
-		for key := range sourceTypes {
-			sourceTypesKeys = append(sourceTypesKeys, key)
-		}
+// GetSourceTypes returns all the source types from the database, sorted by their names.
+func (sdb *SourceTypesDb) GetSourceTypes() []SourceType {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	var result = make([]SourceType, 0, len(sdb.sourceTypes))
+	for _, st := range sdb.sourceTypes {
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// GetRandomSourceType returns a random source type from the database, picked with the given random generator.
+func (sdb *SourceTypesDb) GetRandomSourceType(r *rand.Rand) SourceType {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	// When the user specified weights for the source types, pick the source type proportionally to its weight.
+	if len(sdb.options.SourceTypeWeights) > 0 {
+		return sdb.sourceTypes[sdb.getWeightedRandomKey(r)]
 	}
 
 	// Get a random index for the keys array.
-	randomIdx := rand.Intn(len(sourceTypesKeys))
+	randomIdx := r.Intn(len(sdb.sourceTypesKeys))
 
 	// Get a random key from the keys array.
-	randomKey := sourceTypesKeys[randomIdx]
+	randomKey := sdb.sourceTypesKeys[randomIdx]
 
 	// Return a random source type.
-	return sourceTypes[randomKey]
+	return sdb.sourceTypes[randomKey]
 }
 
-func (sdb SourceTypesDb) InitializeDatabase() {
-	getSourceTypes()
-	getApplicationTypes()
+// GetSourceTypeByIndex returns the source type of the tenant's source with the given index, when the user specified
+// the exact number of sources per source type. The indexes go from zero to the total number of sources per tenant, and
+// it returns an error for any other index.
+func (sdb *SourceTypesDb) GetSourceTypeByIndex(index int) (SourceType, error) {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	if index < 0 || index >= len(sdb.countedSourceTypesKeys) {
+		return SourceType{}, fmt.Errorf(`the source index %d is out of the range of the %d sources from the source type counts`, index, len(sdb.countedSourceTypesKeys))
+	}
+
+	return sdb.sourceTypes[sdb.countedSourceTypesKeys[index]], nil
 }
 
-// getSourceTypes sends a request to fetch all the source types and stores them in the database.
-func getSourceTypes() {
-	// Three seconds is more than enough to hit the API and receive a response. We don't have thousands of source types.
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// GetCountedSourcesPerTenant returns the number of sources to create for each tenant when the user specified the exact
+// number of sources per source type.
+func (sdb *SourceTypesDb) GetCountedSourcesPerTenant() int {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.SourceTypesUrl, nil)
-	if err != nil {
-		logger.Logger.Fatalw(
-			"could not create the request for the source types",
-			zap.Error(err),
-			zap.Any("request", req),
-		)
+	return len(sdb.countedSourceTypesKeys)
+}
+
+// getWeightedRandomKey returns a random source type id, picked proportionally to the configured weights of the source
+// types. The source types without a configured weight have a weight of 1. The caller must hold the lock.
+func (sdb *SourceTypesDb) getWeightedRandomKey(r *rand.Rand) string {
+	var totalWeight float64
+	for _, key := range sdb.sourceTypesKeys {
+		totalWeight += sdb.getSourceTypeWeight(key)
+	}
+
+	target := r.Float64() * totalWeight
+	for _, key := range sdb.sourceTypesKeys {
+		target -= sdb.getSourceTypeWeight(key)
+		if target < 0 {
+			return key
+		}
 	}
 
-	req.Header.Set("Accept", "application/json")
-	// A "x-rh-identity" with an "account number: 12345"
-	req.Header.Set("x-rh-identity", "ewogICAgImlkZW50aXR5IjogewogICAgICAgICJhY2NvdW50X251bWJlciI6ICIxMjM0NSIKICAgIH0KfQ==")
+	// Only reachable due to rounding errors, since the total weight is never zero.
+	return sdb.sourceTypesKeys[len(sdb.sourceTypesKeys)-1]
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logger.Logger.Fatalw(
-			"could not get the source types",
-			zap.Error(err),
-			zap.Any("request", req),
-			zap.Any("response", resp),
-		)
+// getSourceTypeWeight returns the configured weight for the given source type id. The caller must hold the lock.
+func (sdb *SourceTypesDb) getSourceTypeWeight(sourceTypeId string) float64 {
+	weight, ok := sdb.options.SourceTypeWeights[sdb.sourceTypes[sourceTypeId].Name]
+	if !ok {
+		return 1
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return weight
+}
+
+// InitializeDatabase loads the source types and the application types with the given loader, stores them in the
+// database and logs the catalogue's integrity issues. It exits the program if the catalogue cannot be loaded.
+func (sdb *SourceTypesDb) InitializeDatabase(l Loader) {
+	catalogue, err := l.Load()
 	if err != nil {
-		logger.Logger.Fatalw(`could not read the body from the "get source types" response`,
-			zap.Error(err),
-			zap.Any("request", req),
-			zap.Any("response", resp),
-		)
+		logger.Logger.Fatalw("could not load the source types and application types", zap.Error(err))
 	}
 
-	if err := resp.Body.Close(); err != nil {
-		logger.Logger.Fatalw(`could not close the body from the "get source types" response`,
-			zap.Error(err),
-			zap.Any("request", req),
-			zap.Any("response", resp),
+	logger.Logger.Infow(
+		"loaded the source types and application types",
+		zap.Int("source_types", len(catalogue.SourceTypes)),
+		zap.Int("application_types", len(catalogue.ApplicationTypes)),
+	)
+
+	// Export the whole catalogue before filtering it, so that the filters can still be changed when replaying it.
+	if sdb.options.ExportFile != "" {
+		if err := writeCatalogueFile(sdb.options.ExportFile, catalogue); err != nil {
+			logger.Logger.Fatalw("could not export the source types and application types", zap.Error(err))
+		}
+
+		logger.Logger.Infow(
+			"exported the source types and application types",
+			zap.String("source_types_export_file", sdb.options.ExportFile),
 		)
 	}
 
-	// Temporary struct since the JSON comes in the following format: {"data": [{...}, {...}, {...}]}. We cannot
-	// marshal the payload into a "[]model.SourceType" because the IDs come as strings and the model has an int id.
-	// Therefore, the "Unmarshal" function fails.
-	type Auth struct {
-		Type string `json:"type"`
+	if err := sdb.Store(catalogue); err != nil {
+		logger.Logger.Fatalw("could not store the source types and application types", zap.Error(err))
 	}
 
-	type Schema struct {
-		Authentication []Auth `json:"authentication"`
+	sdb.reportIssues()
+}
+
+// Store replaces the contents of the database with the given catalogue, after applying the source type and application
+// type filters, and it looks for integrity issues in it, which can be retrieved with "GetIssues". The new contents are
+// built aside and swapped in at once, so that the concurrent readers never see a partially stored catalogue.
+func (sdb *SourceTypesDb) Store(catalogue Catalogue) error {
+	next := NewSourceTypesDb(sdb.options)
+	next.storeSourceTypes(catalogue.SourceTypes)
+	next.storeApplicationTypes(catalogue.ApplicationTypes)
+
+	if len(next.sourceTypes) == 0 {
+		return fmt.Errorf(`there are no source types left after applying the source type filters`)
 	}
 
-	type SourceType struct {
-		Id     string `json:"id"`
-		Name   string `json:"name"`
-		Schema Schema `json:"schema"`
+	// Build the sorted list of keys up front, since the random source types are picked concurrently.
+	next.sourceTypesKeys = make([]string, 0, len(next.sourceTypes))
+	for key := range next.sourceTypes {
+		next.sourceTypesKeys = append(next.sourceTypesKeys, key)
 	}
+	sort.Strings(next.sourceTypesKeys)
 
-	type SourceTypeResponse struct {
-		SourceTypes []SourceType `json:"data"`
+	if err := next.validateSourceTypeNames(); err != nil {
+		return err
 	}
 
-	// Unmarshal the JSON response into the above structs.
-	var responseBody SourceTypeResponse
-	if err := json.Unmarshal(body, &responseBody); err != nil {
-		logger.Logger.Fatalw(
-			"could not marshal the source types to a struct",
-			zap.Error(err),
-			zap.Any("response_body", responseBody),
-		)
+	next.issues = next.validateCatalogue(catalogue)
+
+	// Expand the exact number of sources per source type into a source type per source.
+	for _, st := range next.GetSourceTypes() {
+		for i := 0; i < sdb.options.SourceTypeCounts[st.Name]; i++ {
+			next.countedSourceTypesKeys = append(next.countedSourceTypesKeys, st.Id)
+		}
 	}
 
-	for _, st := range responseBody.SourceTypes {
-		// We don't want to store the "rh-marketplace" source_types_db for the moment since it doesn't have any compatible apps
-		// or authorizations for it.
-		if st.Name == "rh-marketplace" {
+	sdb.mutex.Lock()
+	defer sdb.mutex.Unlock()
+
+	sdb.sourceNameId = next.sourceNameId
+	sdb.sourceTypes = next.sourceTypes
+	sdb.sourceTypesKeys = next.sourceTypesKeys
+	sdb.countedSourceTypesKeys = next.countedSourceTypesKeys
+	sdb.issues = next.issues
+
+	return nil
+}
+
+// validateSourceTypeNames checks that the source types the user specified weights or counts for exist. The unknown
+// source types in the counts are an error, since their sources cannot be created, whereas the unknown ones in the
+// weights are just ignored. It also checks that the weights leave at least one source type to pick.
+func (sdb *SourceTypesDb) validateSourceTypeNames() error {
+	for name := range sdb.options.SourceTypeCounts {
+		if _, ok := sdb.sourceNameId[name]; !ok {
+			return fmt.Errorf(`unknown or filtered out source type "%s" in the source type counts`, name)
+		}
+	}
+
+	for name := range sdb.options.SourceTypeWeights {
+		if _, ok := sdb.sourceNameId[name]; !ok {
+			logger.Logger.Warnw(
+				"unknown or filtered out source type in the source type weights. Ignoring it...",
+				zap.String("source_type", name),
+			)
+		}
+	}
+
+	// The weights are only known to add up to zero once the catalogue is filtered, since the source types without a
+	// weight have a weight of 1.
+	if len(sdb.options.SourceTypeWeights) > 0 {
+		var totalWeight float64
+		for _, key := range sdb.sourceTypesKeys {
+			totalWeight += sdb.getSourceTypeWeight(key)
+		}
+
+		if totalWeight == 0 {
+			return fmt.Errorf(`every source type has a weight of zero, so there are no source types to pick`)
+		}
+	}
+
+	return nil
+}
+
+// storeSourceTypes stores the given source types and their compatible authentication types in the database.
+func (sdb *SourceTypesDb) storeSourceTypes(sourceTypes []SourceTypeResponse) {
+	var names []string
+	for _, st := range sourceTypes {
+		names = append(names, st.Name)
+
+		// Skip the source types the user filtered out.
+		if !isTypeIncluded(st.Name, sdb.options.IncludeSourceTypes, sdb.options.ExcludeSourceTypes) {
 			continue
 		}
 
 		// Create all the source types and their compatible authentication types.
-		SourceTypesDb{}.CreateSourceType(st.Id, st.Name)
+		sdb.CreateSourceType(st.Id, st.Name)
 		for _, auth := range st.Schema.Authentication {
-			SourceTypesDb{}.AddAuthenticationType(st.Id, auth.Type)
+			sdb.AddAuthenticationType(st.Id, auth.Type)
 		}
 	}
+
+	warnUnknownTypeNames("source type", names, sdb.options.IncludeSourceTypes, sdb.options.ExcludeSourceTypes)
 }
 
-// getApplicationTypes sends a request to the back end to fetch all the application types, and then it relates them to
-// the existing source types from the database.
-func getApplicationTypes() {
-	// Three seconds is more than enough to hit the API and receive a response. We don't have thousands of application
-	// types.
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// storeApplicationTypes relates the given application types to the existing source types from the database.
+func (sdb *SourceTypesDb) storeApplicationTypes(applicationTypes []ApplicationTypeResponse) {
+	// Add all the compatible application types to the already existing source types. Also store the compatible
+	// authentication types for those applications.
+	var names []string
+	for _, appType := range applicationTypes {
+		names = append(names, appType.Name)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, config.ApplicationTypesUrl, nil)
-	if err != nil {
-		logger.Logger.Fatalw(
-			"could not create the request for the application types",
-			zap.Error(err),
-			zap.Any("request", req),
-		)
-	}
+		// Skip the application types the user filtered out.
+		if !isTypeIncluded(appType.Name, sdb.options.IncludeApplicationTypes, sdb.options.ExcludeApplicationTypes) {
+			continue
+		}
 
-	req.Header.Set("Accept", "application/json")
-	// A "x-rh-identity" with an "account number: 12345"
-	req.Header.Set("x-rh-identity", "ewogICAgImlkZW50aXR5IjogewogICAgICAgICJhY2NvdW50X251bWJlciI6ICIxMjM0NSIKICAgIH0KfQ==")
+		// Don't relate the application type to the source types that were filtered out.
+		var supportedSourceTypes []string
+		for _, sst := range appType.SupportedSourceTypes {
+			if isTypeIncluded(sst, sdb.options.IncludeSourceTypes, sdb.options.ExcludeSourceTypes) {
+				supportedSourceTypes = append(supportedSourceTypes, sst)
+			}
+		}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logger.Logger.Fatalw(
-			"could not get the application types",
-			zap.Error(err),
-			zap.Any("request", req),
-			zap.Any("response", resp),
-		)
+		sdb.AddCompatibleApplicationType(appType.Id, appType.Name, supportedSourceTypes, appType.SupportedAuthenticationTypes)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logger.Logger.Fatalw(`could not read the body from the "get source types" response`,
-			zap.Error(err),
-			zap.Any("request", req),
-			zap.Any("response", resp),
-		)
-	}
+	warnUnknownTypeNames("application type", names, sdb.options.IncludeApplicationTypes, sdb.options.ExcludeApplicationTypes)
+}
 
-	if err := resp.Body.Close(); err != nil {
-		logger.Logger.Fatalw(`could not close the body from the "get application types" response`,
-			zap.Error(err),
-			zap.Any("request", req),
-			zap.Any("response", resp),
-		)
+// isTypeIncluded returns true when the given source type or application type name is in the inclusion list, or when
+// that list is empty, and it is not in the exclusion list.
+func isTypeIncluded(name string, include []string, exclude []string) bool {
+	for _, filter := range exclude {
+		if matchesTypeName(name, filter) {
+			return false
+		}
 	}
 
-	// Temporary structs to be able to read the response, since it comes in the {"data": [{...}]} form.
-	type AppType struct {
-		Id                           string              `json:"id"`
-		Name                         string              `json:"name"`
-		SupportedSourceTypes         []string            `json:"supported_source_types"`
-		SupportedAuthenticationTypes map[string][]string `json:"supported_authentication_types"`
+	if len(include) == 0 {
+		return true
 	}
 
-	type AppTypeResponse struct {
-		AppTypes []AppType `json:"data"`
+	for _, filter := range include {
+		if matchesTypeName(name, filter) {
+			return true
+		}
 	}
 
-	// Unmarshal the JSON response into the above structs.
-	var responseBody AppTypeResponse
-	if err := json.Unmarshal(body, &responseBody); err != nil {
-		logger.Logger.Fatalw(
-			"could not marshal application types to struct",
-			zap.Error(err),
-			zap.Any("response_body", responseBody),
-		)
-	}
+	return false
+}
 
-	// Add all the compatible application types to the already existing source types. Also store the compatible
-	// authentication types for those applications.
-	for _, appType := range responseBody.AppTypes {
-		SourceTypesDb{}.AddCompatibleApplicationType(appType.Id, appType.SupportedSourceTypes, appType.SupportedAuthenticationTypes)
+// matchesTypeName returns true when the filter is either the full name of the type or its last path segment, so that
+// the "/insights/platform/cost-management" application type can be filtered as "cost-management".
+func matchesTypeName(name string, filter string) bool {
+	return name == filter || path.Base(name) == filter
+}
+
+// warnUnknownTypeNames warns about the filters that don't match any of the given type names, since they are most
+// likely typos.
+func warnUnknownTypeNames(kind string, names []string, filterLists ...[]string) {
+	for _, filters := range filterLists {
+		for _, filter := range filters {
+			var found bool
+			for _, name := range names {
+				if matchesTypeName(name, filter) {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				logger.Logger.Warnw(
+					fmt.Sprintf("the %s filter doesn't match any %s. Ignoring it...", kind, kind),
+					zap.String("filter", filter),
+				)
+			}
+		}
 	}
 }
@@ -0,0 +1,122 @@
+package source_types_db
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// testCatalogue returns a small catalogue with two source types, one of them with an application type.
+func testCatalogue() Catalogue {
+	return Catalogue{
+		SourceTypes: []SourceTypeResponse{
+			{Id: "1", Name: "amazon", Schema: SchemaResponse{Authentication: []AuthenticationResponse{{Type: "arn"}}}},
+			{Id: "2", Name: "azure", Schema: SchemaResponse{Authentication: []AuthenticationResponse{{Type: "lighthouse_subscription_id"}}}},
+		},
+		ApplicationTypes: []ApplicationTypeResponse{
+			{
+				Id:                           "10",
+				Name:                         "/insights/platform/cost-management",
+				SupportedSourceTypes:         []string{"amazon", "azure"},
+				SupportedAuthenticationTypes: map[string][]string{"amazon": {"arn"}, "azure": {"lighthouse_subscription_id"}},
+			},
+		},
+	}
+}
+
+// TestConcurrentDatabases builds two databases with different options from the same catalogue, and reads them
+// concurrently to check that neither of them sees the other one's filters.
+func TestConcurrentDatabases(t *testing.T) {
+	logger.Logger = zap.NewNop().Sugar()
+
+	amazonDb := NewSourceTypesDb(Options{IncludeSourceTypes: []string{"amazon"}})
+	azureDb := NewSourceTypesDb(Options{ExcludeSourceTypes: []string{"amazon"}})
+
+	for _, sdb := range []*SourceTypesDb{amazonDb, azureDb} {
+		if err := sdb.Store(testCatalogue()); err != nil {
+			t.Fatalf("could not store the catalogue: %s", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		for sdb, want := range map[*SourceTypesDb]string{amazonDb: "amazon", azureDb: "azure"} {
+			wg.Add(1)
+			go func(sdb *SourceTypesDb, want string, seed int64) {
+				defer wg.Done()
+
+				r := rand.New(rand.NewSource(seed))
+				for j := 0; j < 100; j++ {
+					st := sdb.GetRandomSourceType(r)
+					if st.Name != want {
+						t.Errorf(`want source type "%s", got "%s"`, want, st.Name)
+						return
+					}
+
+					if authType := sdb.GetRandomAuthenticationTypeForSource(r, st.Id); authType == "" {
+						t.Errorf(`want an authentication type for the source type "%s", got none`, st.Name)
+						return
+					}
+
+					if appTypes := sdb.GetApplicationTypes(st.Id); len(appTypes) != 1 {
+						t.Errorf(`want 1 application type for the source type "%s", got %d`, st.Name, len(appTypes))
+						return
+					}
+				}
+			}(sdb, want, int64(i))
+		}
+	}
+
+	wg.Wait()
+}
+
+// TestSourceTypeWithoutAuthentications checks that the source types and the application types without authentication
+// types don't make the random authentication types panic, and that the application types which support a filtered out
+// source type are reported.
+func TestSourceTypeWithoutAuthentications(t *testing.T) {
+	logger.Logger = zap.NewNop().Sugar()
+
+	catalogue := testCatalogue()
+	catalogue.SourceTypes = append(catalogue.SourceTypes, SourceTypeResponse{Id: "3", Name: "rh-marketplace"})
+	catalogue.ApplicationTypes = append(catalogue.ApplicationTypes, ApplicationTypeResponse{
+		Id:                           "11",
+		Name:                         "/insights/platform/cloud-meter",
+		SupportedSourceTypes:         []string{"azure", "rh-marketplace"},
+		SupportedAuthenticationTypes: map[string][]string{"azure": {}},
+	})
+
+	sdb := NewSourceTypesDb(Options{ExcludeSourceTypes: []string{"amazon"}})
+	if err := sdb.Store(catalogue); err != nil {
+		t.Fatalf("could not store the catalogue: %s", err)
+	}
+
+	r := rand.New(rand.NewSource(0))
+	if sdb.HasAuthenticationTypes("3") {
+		t.Errorf(`want no authentication types for the "rh-marketplace" source type`)
+	}
+	if authType := sdb.GetRandomAuthenticationTypeForSource(r, "3"); authType != "" {
+		t.Errorf(`want no authentication type for the "rh-marketplace" source type, got "%s"`, authType)
+	}
+	if authType := sdb.GetRandomAuthenticationTypeForApplication(r, "2", "11"); authType == "" {
+		t.Errorf(`want the fixed authentication type for the cloud meter application on "azure", got none`)
+	}
+
+	var filtered []string
+	for _, issue := range sdb.GetIssues() {
+		if issue.Kind == IssueFilteredSourceType {
+			filtered = append(filtered, issue.SourceType)
+		}
+	}
+	if len(filtered) != 1 || filtered[0] != "amazon" {
+		t.Errorf(`want the "amazon" source type reported as filtered out, got %v`, filtered)
+	}
+
+	for _, issue := range sdb.GetIssues() {
+		if issue.Kind == IssueFilteredSourceType && issue.IsIntegrityIssue() {
+			t.Errorf(`want the filtered out source types not to be integrity issues`)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package source_types_db
+
+import (
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"go.uber.org/zap"
+)
+
+// Kinds of integrity issues of the catalogue.
+const (
+	// IssueDanglingSourceType is an application type which supports a source type that doesn't exist.
+	IssueDanglingSourceType = "dangling_source_type"
+	// IssueFilteredSourceType is an application type which supports a source type that was filtered out, so the
+	// application type is not related to it. It is caused by the user's filters, so it is only informational.
+	IssueFilteredSourceType = "filtered_source_type"
+	// IssueSourceTypeWithoutAuthentications is a source type which doesn't support any authentication type, so its
+	// sources cannot get any authentications.
+	IssueSourceTypeWithoutAuthentications = "source_type_without_authentication_types"
+	// IssueUnsupportedAuthenticationType is an authentication type that an application type declares for a source
+	// type, but that the source type itself doesn't support.
+	IssueUnsupportedAuthenticationType = "unsupported_authentication_type"
+)
+
+// CatalogueIssue is an integrity issue of the catalogue, which is most likely a bug in the back end's seeds.
+type CatalogueIssue struct {
+	Kind               string `json:"kind"`
+	SourceType         string `json:"source_type"`
+	ApplicationType    string `json:"application_type,omitempty"`
+	AuthenticationType string `json:"authentication_type,omitempty"`
+	Message            string `json:"message"`
+}
+
+// IsIntegrityIssue returns true when the issue is a problem of the catalogue itself, rather than a consequence of the
+// user's filters.
+func (issue CatalogueIssue) IsIntegrityIssue() bool {
+	return issue.Kind != IssueFilteredSourceType
+}
+
+// GetIssues returns the integrity issues found in the stored catalogue.
+func (sdb *SourceTypesDb) GetIssues() []CatalogueIssue {
+	sdb.mutex.RLock()
+	defer sdb.mutex.RUnlock()
+
+	return sdb.issues
+}
+
+// reportIssues logs the issues found in the stored catalogue: the integrity issues as warnings, and the rest of them as
+// information.
+func (sdb *SourceTypesDb) reportIssues() {
+	issues := sdb.GetIssues()
+
+	for _, issue := range issues {
+		log := logger.Logger.Infow
+		if issue.IsIntegrityIssue() {
+			log = logger.Logger.Warnw
+		}
+
+		log(
+			issue.Message,
+			zap.String("kind", issue.Kind),
+			zap.String("source_type", issue.SourceType),
+			zap.String("application_type", issue.ApplicationType),
+			zap.String("authentication_type", issue.AuthenticationType),
+		)
+	}
+}
+
+// validateCatalogue looks for integrity issues in the given catalogue and in the source types and application types
+// that were stored from it. The source types and application types which were filtered out are not validated, but the
+// application types which support them are reported.
+func (sdb *SourceTypesDb) validateCatalogue(catalogue Catalogue) []CatalogueIssue {
+	var issues []CatalogueIssue
+
+	sourceTypeNames := make(map[string]bool, len(catalogue.SourceTypes))
+	for _, st := range catalogue.SourceTypes {
+		sourceTypeNames[st.Name] = true
+	}
+
+	// The application types that support source types which are not in the catalogue, or which were filtered out.
+	for _, appType := range catalogue.ApplicationTypes {
+		if !isTypeIncluded(appType.Name, sdb.options.IncludeApplicationTypes, sdb.options.ExcludeApplicationTypes) {
+			continue
+		}
+
+		for _, sst := range appType.SupportedSourceTypes {
+			if !sourceTypeNames[sst] {
+				issues = append(issues, CatalogueIssue{
+					Kind:            IssueDanglingSourceType,
+					SourceType:      sst,
+					ApplicationType: appType.Name,
+					Message:         "the application type supports a source type which doesn't exist",
+				})
+			} else if !isTypeIncluded(sst, sdb.options.IncludeSourceTypes, sdb.options.ExcludeSourceTypes) {
+				issues = append(issues, CatalogueIssue{
+					Kind:            IssueFilteredSourceType,
+					SourceType:      sst,
+					ApplicationType: appType.Name,
+					Message:         "the application type supports a source type which was filtered out",
+				})
+			}
+		}
+	}
+
+	for _, st := range sdb.GetSourceTypes() {
+		if len(st.CompatibleAuthentications) == 0 {
+			issues = append(issues, CatalogueIssue{
+				Kind:       IssueSourceTypeWithoutAuthentications,
+				SourceType: st.Name,
+				Message:    "the source type doesn't support any authentication type",
+			})
+		}
+
+		supportedAuthentications := make(map[string]bool, len(st.CompatibleAuthentications))
+		for _, authType := range st.CompatibleAuthentications {
+			supportedAuthentications[authType] = true
+		}
+
+		for _, appType := range sdb.GetApplicationTypes(st.Id) {
+			for _, authType := range appType.CompatibleAuthentications {
+				if !supportedAuthentications[authType] {
+					issues = append(issues, CatalogueIssue{
+						Kind:               IssueUnsupportedAuthenticationType,
+						SourceType:         st.Name,
+						ApplicationType:    appType.Name,
+						AuthenticationType: authType,
+						Message:            "the application type declares an authentication type which the source type doesn't support",
+					})
+				}
+			}
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,126 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencySummary holds the latency statistics, in milliseconds, and the throughput of the creation requests of a
+// resource type.
+type LatencySummary struct {
+	Requests          int     `json:"requests"`
+	MinMs             float64 `json:"min_ms"`
+	MeanMs            float64 `json:"mean_ms"`
+	P50Ms             float64 `json:"p50_ms"`
+	P90Ms             float64 `json:"p90_ms"`
+	P99Ms             float64 `json:"p99_ms"`
+	MaxMs             float64 `json:"max_ms"`
+	RequestsPerSecond float64 `json:"requests_per_second"`
+}
+
+// mutex protects the recorded latencies and retries, since the requests are sent from many goroutines at the same time.
+var mutex sync.Mutex
+
+// latencies holds the recorded latencies of the creation requests per resource type.
+var latencies = make(map[string][]time.Duration)
+
+// retries holds the number of retried creation requests per resource type.
+var retries = make(map[string]uint64)
+
+// RecordRetry records a retry of a creation request for the given resource type.
+func RecordRetry(resourceType string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	retries[resourceType]++
+}
+
+// GetRetries returns the number of retried creation requests per resource type.
+func GetRetries() map[string]uint64 {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	result := make(map[string]uint64, len(retries))
+	for resourceType, count := range retries {
+		result[resourceType] = count
+	}
+
+	return result
+}
+
+// RecordLatency records the latency of a creation request for the given resource type.
+func RecordLatency(resourceType string, latency time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	latencies[resourceType] = append(latencies[resourceType], latency)
+}
+
+// GetSummaries returns the latency statistics for every resource type which had requests recorded, along with the
+// overall throughput of the run, calculated for the given elapsed time.
+func GetSummaries(elapsedTime time.Duration) (map[string]LatencySummary, float64) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var totalRequests int
+	summaries := make(map[string]LatencySummary, len(latencies))
+	for resourceType, recorded := range latencies {
+		summaries[resourceType] = summarize(recorded, elapsedTime)
+		totalRequests += len(recorded)
+	}
+
+	return summaries, getRate(totalRequests, elapsedTime)
+}
+
+// summarize calculates the statistics of the given latencies. The given slice gets sorted in the process.
+func summarize(recorded []time.Duration, elapsedTime time.Duration) LatencySummary {
+	if len(recorded) == 0 {
+		return LatencySummary{}
+	}
+
+	sort.Slice(recorded, func(i, j int) bool {
+		return recorded[i] < recorded[j]
+	})
+
+	var total time.Duration
+	for _, latency := range recorded {
+		total += latency
+	}
+
+	return LatencySummary{
+		Requests:          len(recorded),
+		MinMs:             toMilliseconds(recorded[0]),
+		MeanMs:            toMilliseconds(total / time.Duration(len(recorded))),
+		P50Ms:             toMilliseconds(getPercentile(recorded, 50)),
+		P90Ms:             toMilliseconds(getPercentile(recorded, 90)),
+		P99Ms:             toMilliseconds(getPercentile(recorded, 99)),
+		MaxMs:             toMilliseconds(recorded[len(recorded)-1]),
+		RequestsPerSecond: getRate(len(recorded), elapsedTime),
+	}
+}
+
+// getPercentile returns the given percentile of the sorted latencies, using the nearest rank method.
+func getPercentile(sorted []time.Duration, percentile float64) time.Duration {
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+// getRate returns the number of requests per second for the given elapsed time.
+func getRate(requests int, elapsedTime time.Duration) float64 {
+	if elapsedTime <= 0 {
+		return 0
+	}
+
+	return math.Round(float64(requests)/elapsedTime.Seconds()*100) / 100
+}
+
+// toMilliseconds converts the given duration to milliseconds, rounded to three decimals.
+func toMilliseconds(duration time.Duration) float64 {
+	return math.Round(float64(duration.Microseconds())) / 1000
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/MikelAlejoBR/sources-database-populator/config"
+	"github.com/MikelAlejoBR/sources-database-populator/logger"
+	"github.com/MikelAlejoBR/sources-database-populator/manifest"
+	"github.com/MikelAlejoBR/sources-database-populator/metrics"
+	"github.com/MikelAlejoBR/sources-database-populator/request"
+	"go.uber.org/zap"
+)
+
+// verificationResult holds the number of resources that were found, that were missing and that could not be checked
+// for a resource type.
+type verificationResult struct {
+	Found   uint64 `json:"found"`
+	Missing uint64 `json:"missing"`
+	Failed  uint64 `json:"failed"`
+}
+
+// verify checks that the resources recorded in the manifest file still exist in the back end. When no manifest is
+// given, it counts the resources that the given tenants have instead. It prints the results per resource type at the
+// end.
+func verify() {
+	// Get the time before starting the process so that we can calculate the elapsed time afterwards.
+	startTs := time.Now()
+
+	var summary map[string]interface{}
+	if config.ManifestFile != "" {
+		summary = verifyManifest()
+	} else {
+		summary = countTenantResources()
+	}
+
+	summary["elapsed_time"] = time.Since(startTs).String()
+
+	// Just like in the other modes, we print the results instead of logging them so that they are always visible
+	// regardless of the log level.
+	result, err := json.Marshal(summary)
+	if err == nil {
+		fmt.Println(string(result))
+	} else {
+		logger.Logger.Errorw(
+			"Could not format the results to JSON. Printing it on this log message",
+			zap.Error(err),
+			zap.Any("summary", summary),
+		)
+	}
+}
+
+// verifyManifest fetches every resource recorded in the manifest file and returns how many of them were found in the
+// back end, per resource type.
+func verifyManifest() map[string]interface{} {
+	entries, err := manifest.ReadManifest(config.ManifestFile)
+	if err != nil {
+		logger.Logger.Fatalw(
+			"could not read the manifest file",
+			zap.Error(err),
+			zap.String("manifest_file", config.ManifestFile),
+		)
+	}
+
+	resources := getCleanupResources()
+
+	urls := make(map[string]string, len(resources))
+	results := make(map[string]*verificationResult, len(resources))
+	for _, resource := range resources {
+		urls[resource.Name] = resource.Url
+		results[resource.Name] = &verificationResult{}
+	}
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		result, ok := results[entry.ResourceType]
+		if !ok {
+			logger.Logger.Errorw(
+				"unknown resource type in the manifest. Skipping...",
+				zap.String("resource_type", entry.ResourceType),
+				zap.String("id", entry.Id),
+			)
+			continue
+		}
+
+		wg.Add(1)
+		go func(entry manifest.Entry) {
+			defer wg.Done()
+
+			found, isSuccess := sendExistenceRequest(entry.ResourceType, entry.Tenant, fmt.Sprintf("%s/%s", urls[entry.ResourceType], entry.Id))
+			switch {
+			case !isSuccess:
+				atomic.AddUint64(&result.Failed, 1)
+			case found:
+				atomic.AddUint64(&result.Found, 1)
+			default:
+				atomic.AddUint64(&result.Missing, 1)
+
+				logger.Logger.Infow(
+					"Resource missing",
+					zap.String("resource_type", entry.ResourceType),
+					zap.String("id", entry.Id),
+					zap.String("tenant", entry.Tenant),
+				)
+			}
+		}(entry)
+	}
+
+	wg.Wait()
+
+	return map[string]interface{}{
+		"verified_sources":         results["source"],
+		"verified_endpoints":       results["endpoint"],
+		"verified_applications":    results["application"],
+		"verified_authentications": results["authentication"],
+		"verified_rhc_connections": results["rhcConnection"],
+	}
+}
+
+// countTenantResources returns the number of resources of each type that every given tenant has in the back end.
+func countTenantResources() map[string]interface{} {
+	counts := make(map[string]map[string]int, len(config.Tenants))
+	for _, tenant := range config.Tenants {
+		counts[tenant] = make(map[string]int)
+
+		for _, resource := range getCleanupResources() {
+			counts[tenant][resource.Name] = len(listResourceIds(tenant, resource))
+		}
+	}
+
+	return map[string]interface{}{
+		"tenant_resources": counts,
+	}
+}
+
+// sendExistenceRequest sends a "GET" request to the given resource URL, and returns whether the resource exists. The
+// second return value is false when the request could not be sent or the back end responded with an unexpected
+// status code.
+func sendExistenceRequest(resourceType string, tenant string, url string) (bool, bool) {
+	config.ConcurrentRequests <- struct{}{}
+	defer func() { <-config.ConcurrentRequests }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.GetTimeout(resourceType))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		logger.Logger.Errorw(
+			"could not create the verification request. Skipping...",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return false, false
+	}
+
+	request.SetHeaders(req, tenant)
+
+	logger.Logger.Debugw("Verification request to be sent", zap.Any("request", req))
+
+	metrics.RequestStarted(resourceType, tenant, http.MethodGet)
+	requestStartTs := time.Now()
+	res, err := request.Client.Do(req)
+	latency := time.Since(requestStartTs)
+	if err != nil {
+		metrics.ObserveRequest(resourceType, tenant, http.MethodGet, 0, latency, false)
+
+		logger.Logger.Errorw(
+			"could not send the verification request. Skipping...",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return false, false
+	}
+
+	metrics.ObserveRequest(resourceType, tenant, http.MethodGet, res.StatusCode, latency, res.StatusCode == http.StatusOK || res.StatusCode == http.StatusNotFound)
+
+	if err = res.Body.Close(); err != nil {
+		logger.Logger.Errorw(
+			"could not close the verification response body",
+			zap.Error(err),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+	}
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, true
+	case http.StatusNotFound:
+		return false, true
+	default:
+		logger.Logger.Errorw(
+			"unexpected status code when verifying a resource. Skipping...",
+			zap.Int("got_status_code", res.StatusCode),
+			zap.String("resource_type", resourceType),
+			zap.String("tenant", tenant),
+			zap.String("url", url),
+		)
+		return false, false
+	}
+}